@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/staparx/go_showstart/client"
+	"github.com/staparx/go_showstart/config"
+	"github.com/staparx/go_showstart/grabber"
+	"github.com/staparx/go_showstart/log"
+	"github.com/staparx/go_showstart/vars"
+	"go.uber.org/zap"
+)
+
+// runCheck 按 config.yaml 执行开售前检查并输出报告，退出码见 grabber.CheckExitOK 等
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	jobName := fs.String("job", "", "只检查指定名称的抢票任务")
+	if err := fs.Parse(args); err != nil {
+		return grabber.CheckExitError
+	}
+
+	log.InitLogger()
+	loc, err := time.LoadLocation(vars.TimeLoadLocation)
+	if err != nil {
+		loc = time.FixedZone("CST", 8*3600)
+	}
+	vars.TimeLocal = loc
+
+	cfg, err := config.InitCfg()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "配置信息读取失败：", err)
+		return grabber.CheckExitError
+	}
+
+	c := client.NewShowStartClient(cfg.Showstart)
+	return grabber.CheckJobs(context.Background(), cfg, c, *jobName, os.Stdout, os.Stderr)
+}
+
+// reportPrecheck 记录开售前定时检查的结果，未通过时发送邮件提醒
+func reportPrecheck(cfg *config.Config, report grabber.Report) {
+	logger := log.Logger.With(zap.String("job", report.Job))
+	if !report.Failed() {
+		logger.Info("✅开售前检查通过")
+		return
+	}
+	logger.Error("❌ 开售前检查未通过，请尽快处理\n" + report.String())
+	if cfg.SmtpEmail != nil && cfg.SmtpEmail.Enable {
+		subject := fmt.Sprintf("抢票任务 %s 开售前检查未通过，请尽快处理！！！", report.Job)
+		if err := sendEmail(subject, report.String(), cfg); err != nil {
+			logger.Error("发送邮件失败：", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"go.uber.org/zap"
+)
+
+const getTokenPath = "/waf/gettoken"
+
+// envelope 用于在解析具体结构体前检查公共字段
+type envelope struct {
+	ShowStartCommonResp
+	Result jsoniter.RawMessage `json:"result"`
+}
+
+// doRequest 按普通接口的重试策略执行请求
+func doRequest[T any](ctx context.Context, c *ShowStartClient, path, body string) (*T, error) {
+	return doRequestWithPolicy[T](ctx, c, c.retry, path, body)
+}
+
+// doRequestWithPolicy 统一的请求流程：发送、解析公共字段、登录过期自动刷新 token、校验 state，最后解析为具体的响应结构体。
+// 网络错误、5xx、登录过期、pending 是否重试以及重试次数由 policy 决定。
+func doRequestWithPolicy[T any](ctx context.Context, c *ShowStartClient, policy RetryPolicy, path, body string) (*T, error) {
+	if path != getTokenPath {
+		// token 即将过期时提前刷新，失败时仍使用旧 token 继续请求
+		if err := c.creds.EnsureFresh(ctx); err != nil {
+			c.logger.Warn("提前刷新 token 失败", zap.Error(err))
+		}
+	}
+
+	var (
+		resp   *T
+		sentAt time.Time
+	)
+	attempts, err := policy.do(ctx, c.clock, func(ctx context.Context, attempt int, lastErr error) error {
+		if lastErr != nil {
+			if errors.Is(lastErr, ErrPending) {
+				c.logger.Debug("订单处理中，继续查询", zap.String("path", path), zap.Int("attempt", attempt))
+			} else {
+				c.logger.Warn("请求失败，将重试", zap.String("path", path), zap.Int("attempt", attempt), zap.Error(lastErr))
+			}
+			if IsTokenExpired(lastErr) && path != getTokenPath {
+				if err := c.creds.RefreshSince(ctx, sentAt); err != nil {
+					return err
+				}
+			}
+		}
+
+		var err error
+		sentAt = c.clock.Now()
+		resp, err = send[T](ctx, c, path, body, attempt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if attempts > 1 {
+		c.logger.Info("请求重试后成功", zap.String("path", path), zap.Int("attempts", attempts))
+	}
+	return resp, nil
+}
+
+// send 经过拦截器链发送一次请求并解析响应
+func send[T any](ctx context.Context, c *ShowStartClient, path, body string, attempt int) (*T, error) {
+	call := &Call{Path: path, Body: body, Attempt: attempt}
+	if err := c.invoke(ctx, call); err != nil {
+		return nil, err
+	}
+
+	var resp T
+	if c.drift != nil {
+		c.drift.Check(path, reflect.TypeOf(resp), call.Response)
+	}
+	if err := jsoniter.Unmarshal(call.Response, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// roundTrip 拦截器链最内层：发送请求并校验公共字段
+func (c *ShowStartClient) roundTrip(ctx context.Context, call *Call) error {
+	start := c.clock.Now()
+	result, err := c.Post(ctx, call.Path, call.Body)
+	call.Latency = c.clock.Now().Sub(start)
+	call.Response = result
+
+	if err == nil {
+		var env envelope
+		if err = jsoniter.Unmarshal(result, &env); err == nil {
+			err = checkEnvelope(call.Path, &env)
+		}
+	}
+	call.Err = err
+	return err
+}
+
+// checkEnvelope 校验公共字段，返回 *APIError 或 ErrPending
+func checkEnvelope(path string, env *envelope) error {
+	apiErr := newAPIError(path, &env.ShowStartCommonResp)
+	if apiErr.TokenExpired() {
+		return apiErr
+	}
+	if env.State != "1" && !env.Success {
+		return apiErr
+	}
+	if isPendingResult(env.Result) {
+		return ErrPending
+	}
+	return nil
+}
+
+func isPendingResult(raw jsoniter.RawMessage) bool {
+	var s string
+	if len(raw) == 0 || raw[0] != '"' {
+		return false
+	}
+	return jsoniter.Unmarshal(raw, &s) == nil && s == "pending"
+}
@@ -0,0 +1,258 @@
+// Package cassette 录制与回放秀动接口的请求/响应，用于离线复现监控与下单问题，也可作为回归用例。
+//
+// 录制时 token、sign、cookie、set-cookie、cusat、cusit、cusut、cuuserref、cdeviceno、手机号、证件号等敏感信息会被脱敏；
+// 加密的请求体解密后脱敏保存，录制文件不能用于还原账号凭证。
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/staparx/go_showstart/util"
+	"github.com/staparx/go_showstart/vars"
+)
+
+// encryptedBody 加密请求体无法解密时在录制文件中的占位
+const encryptedBody = "[encrypted]"
+
+// Interaction 一次请求与响应
+type Interaction struct {
+	Method         string        `json:"method"`
+	Path           string        `json:"path"`
+	RequestHeader  http.Header   `json:"requestHeader"`
+	RequestBody    string        `json:"requestBody"`
+	Status         int           `json:"status"`
+	ResponseHeader http.Header   `json:"responseHeader"`
+	ResponseBody   string        `json:"responseBody"`
+	Latency        time.Duration `json:"latency"`
+	RecordedAt     time.Time     `json:"recordedAt"`
+}
+
+// Cassette 录制文件内容，文件为 JSON Lines 格式，每行一个 Interaction
+type Cassette struct {
+	Interactions []*Interaction
+}
+
+// Load 读取录制文件
+func Load(file string) (*Cassette, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var c Cassette
+	dec := json.NewDecoder(f)
+	for {
+		var it Interaction
+		if err := dec.Decode(&it); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("录制文件 %s 格式错误: %w", file, err)
+		}
+		c.Interactions = append(c.Interactions, &it)
+	}
+	return &c, nil
+}
+
+// fileMu 同一进程内可能有多个客户端录制到同一个文件
+var fileMu sync.Mutex
+
+// appendInteraction 追加一条记录
+func appendInteraction(file string, it *Interaction) error {
+	data, err := json.Marshal(it)
+	if err != nil {
+		return err
+	}
+
+	fileMu.Lock()
+	defer fileMu.Unlock()
+
+	if dir := filepath.Dir(file); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Recorder 录制经过的请求与响应
+type Recorder struct {
+	file string
+	next http.RoundTripper
+}
+
+// NewRecorder 创建录制 RoundTripper，next 为 nil 时使用 http.DefaultTransport
+func NewRecorder(file string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{file: file, next: next}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	start := time.Now()
+	res, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	latency := time.Since(start)
+
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	it := &Interaction{
+		Method:         req.Method,
+		Path:           req.URL.Path,
+		RequestHeader:  util.RedactHeader(req.Header),
+		RequestBody:    requestBody(req, reqBody),
+		Status:         res.StatusCode,
+		ResponseHeader: util.RedactHeader(res.Header),
+		ResponseBody:   string(util.RedactJSON(resBody)),
+		Latency:        latency,
+		RecordedAt:     start,
+	}
+	if err := appendInteraction(r.file, it); err != nil {
+		fmt.Fprintf(os.Stderr, "写入录制文件失败: %v\n", err)
+	}
+
+	return res, nil
+}
+
+// requestBody 加密接口的请求体先解密，再脱敏后保存；解密失败时不落盘
+func requestBody(req *http.Request, body []byte) string {
+	if vars.EncryptPathMap[apiPath(req.URL.Path)] {
+		plain, err := util.DecryptRequestBody(string(body), req.Header.Get("crtraceid"), req.Header.Get("cdeviceno"))
+		if err != nil {
+			return encryptedBody
+		}
+		body = []byte(plain)
+	}
+	return string(util.RedactJSON(body))
+}
+
+// apiPath 去掉 /v3 等前缀，与 vars.EncryptPathMap 的 key 对齐
+func apiPath(p string) string {
+	for key := range vars.EncryptPathMap {
+		if strings.HasSuffix(p, key) {
+			return key
+		}
+	}
+	return p
+}
+
+// Replayer 按录制顺序回放响应，不访问网络
+type Replayer struct {
+	file    string
+	once    sync.Once
+	loadErr error
+	mu      sync.Mutex
+	byKey   map[string][]*Interaction
+	cursor  map[string]int
+}
+
+var (
+	replayersMu sync.Mutex
+	replayers   = map[string]*Replayer{}
+)
+
+// NewReplayer 返回 file 对应的回放 RoundTripper，同一文件在进程内共享回放进度。
+// 录制文件在第一次请求时读取，读取失败时所有请求都返回该错误。
+func NewReplayer(file string) *Replayer {
+	replayersMu.Lock()
+	defer replayersMu.Unlock()
+	if r, ok := replayers[file]; ok {
+		return r
+	}
+	r := &Replayer{file: file}
+	replayers[file] = r
+	return r
+}
+
+func (r *Replayer) load() {
+	c, err := Load(r.file)
+	if err != nil {
+		r.loadErr = err
+		return
+	}
+	r.byKey = map[string][]*Interaction{}
+	r.cursor = map[string]int{}
+	for _, it := range c.Interactions {
+		key := it.Method + " " + it.Path
+		r.byKey[key] = append(r.byKey[key], it)
+	}
+}
+
+// RoundTrip 按 method + path 依次返回录制的响应；同一接口的录制用完后重复最后一条，便于回放轮询
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.once.Do(r.load)
+	if r.loadErr != nil {
+		return nil, r.loadErr
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	key := req.Method + " " + req.URL.Path
+
+	r.mu.Lock()
+	list := r.byKey[key]
+	if len(list) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("录制文件中没有 %s 的记录", key)
+	}
+	idx := r.cursor[key]
+	if idx >= len(list) {
+		idx = len(list) - 1
+	} else {
+		r.cursor[key] = idx + 1
+	}
+	it := list[idx]
+	r.mu.Unlock()
+
+	header := it.ResponseHeader.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", it.Status, http.StatusText(it.Status)),
+		StatusCode:    it.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(it.ResponseBody)),
+		ContentLength: int64(len(it.ResponseBody)),
+		Request:       req,
+	}, nil
+}
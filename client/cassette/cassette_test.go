@@ -0,0 +1,123 @@
+package cassette_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/staparx/go_showstart/client"
+	"github.com/staparx/go_showstart/client/cassette"
+	"github.com/staparx/go_showstart/client/showstarttest"
+	"github.com/staparx/go_showstart/config"
+)
+
+const (
+	testToken = "abcdefghijklmnopqrstuvwxyz0123456789"
+	testSign  = "sign-0123456789abcdef"
+
+	testSessionCookie = "sessionid=session-0123456789abcdef"
+	testRefreshCookie = "refresh=refresh-0123456789abcdef"
+)
+
+// setCookieTransport 在响应中加入服务端下发的会话 cookie
+type setCookieTransport struct {
+	next http.RoundTripper
+}
+
+func (t setCookieTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err == nil {
+		res.Header.Add("Set-Cookie", testSessionCookie+"; Path=/; HttpOnly")
+		res.Header.Add("Set-Cookie", testRefreshCookie+"; Path=/")
+	}
+	return res, err
+}
+
+func TestRecorderMasksCredentials(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	file := filepath.Join(t.TempDir(), "cassette.jsonl")
+
+	cfg := &config.Showstart{
+		Sign:       testSign,
+		Token:      testToken,
+		Cterminal:  "wap",
+		Cversion:   "997",
+		Cusid:      "1",
+		Cusname:    "nil",
+		Cookie:     "sessionid=cookie-0123456789",
+		BaseURL:    s.BaseURL,
+		RecordFile: file,
+	}
+	ctx := context.Background()
+	c := client.NewShowStartClient(cfg, client.WithRateLimiter(nil), client.WithTransport(setCookieTransport{http.DefaultTransport}))
+	if err := c.GetToken(ctx); err != nil {
+		t.Fatalf("GetToken: %v", err)
+	}
+	if _, err := c.ActivityDetail(ctx, s.Fixture().ActivityID); err != nil {
+		t.Fatalf("ActivityDetail: %v", err)
+	}
+	if _, err := c.Order(ctx, &client.OrderReq{
+		OrderDetails:      []*client.OrderDetail{{SkuID: "ticket-388", Num: "1"}},
+		CommonPerfomerIds: []int{5773864},
+	}); err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{testToken, testSign, cfg.Cookie, "at-1", "it-1", testSessionCookie, testRefreshCookie} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("录制文件包含明文 %q", secret)
+		}
+	}
+	// 每个 Set-Cookie 值都应保留并脱敏
+	cas, err := cassette.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cookies := cas.Interactions[0].ResponseHeader.Values("Set-Cookie"); len(cookies) != 2 {
+		t.Errorf("Set-Cookie = %v, want 2 个值", cookies)
+	}
+	// 加密的下单请求体应已解密并脱敏保存
+	if !strings.Contains(string(data), "ticket-388") {
+		t.Error("录制文件中没有解密后的下单请求体")
+	}
+
+	// 脱敏后的录制文件仍可回放
+	replay := client.NewShowStartClient(&config.Showstart{Token: "replay-token", ReplayFile: file}, client.WithRateLimiter(nil))
+	detail, err := replay.ActivityDetail(ctx, s.Fixture().ActivityID)
+	if err != nil {
+		t.Fatalf("回放 ActivityDetail: %v", err)
+	}
+	if detail.Result.ActivityName != s.Fixture().ActivityName {
+		t.Errorf("回放 activityName = %q", detail.Result.ActivityName)
+	}
+}
+
+func TestRecorderWithHTTPClient(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	file := filepath.Join(t.TempDir(), "cassette.jsonl")
+
+	cfg := &config.Showstart{Token: testToken, Cterminal: "wap", BaseURL: s.BaseURL, RecordFile: file}
+	hc := &http.Client{Timeout: 5 * time.Second}
+	c := client.NewShowStartClient(cfg, client.WithHTTPClient(hc), client.WithRateLimiter(nil))
+	if err := c.GetToken(context.Background()); err != nil {
+		t.Fatalf("GetToken: %v", err)
+	}
+	// 自定义 http.Client 时 record_file 仍然生效，且不修改调用方的 http.Client
+	data, err := os.ReadFile(file)
+	if err != nil || !strings.Contains(string(data), "/waf/gettoken") {
+		t.Fatalf("录制文件 %q, err = %v", data, err)
+	}
+	if hc.Transport != nil {
+		t.Error("调用方的 http.Client 被修改")
+	}
+}
@@ -2,19 +2,18 @@ package client
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
-	"time"
+	"os"
+	"strings"
 
+	"github.com/staparx/go_showstart/client/cassette"
 	"github.com/staparx/go_showstart/config"
 	"github.com/staparx/go_showstart/log"
 	"github.com/staparx/go_showstart/util"
 	"github.com/staparx/go_showstart/vars"
 	"go.uber.org/zap"
-	"strings"
 )
 
 type ClientHeaderConfig struct {
@@ -27,9 +26,7 @@ type ClientHeaderConfig struct {
 	Crtraceid   string `json:"crtraceid"`
 	Csappid     string `json:"csappid"`
 	Cterminal   string `json:"cterminal"`
-	Cusat       string `json:"cusat"` //对应accessToken
 	Cusid       string `json:"cusid"`
-	Cusit       string `json:"cusit"` //对应idToken
 	Cusname     string `json:"cusname"`
 	Cusut       string `json:"cusut"`
 	Cuuserref   string `json:"cuuserref"`
@@ -40,23 +37,47 @@ type ClientHeaderConfig struct {
 type ShowStartClient struct {
 	BashUrl string
 	client  *http.Client
+	// retry 普通接口的重试策略
+	retry RetryPolicy
+	// order 提交订单的重试策略
+	order RetryPolicy
+	// poll 订单结果轮询的重试策略
+	poll RetryPolicy
+	// creds cusat（accessToken）与 cusit（idToken），由 GetToken 刷新
+	creds  *credentialManager
+	logger *zap.Logger
+	clock  Clock
+	signer util.Signer
+	// interceptors 用户添加的拦截器，位于脱敏 Debug 日志之内
+	interceptors []Interceptor
+	// limiter 按接口族限流，默认与进程内其他 client 共享；limiterSet 表示由 WithRateLimiter 指定
+	limiter    *RateLimiter
+	limiterSet bool
+	// drift 响应结构变化检测，为 nil 时不检测
+	drift  *DriftDetector
+	invoke Invoker
 	*ClientHeaderConfig
 }
 
-func NewShowStartClient(ctx context.Context, cfg *config.Showstart) ShowStartIface {
+func NewShowStartClient(cfg *config.Showstart, opts ...Option) ShowStartIface {
+	return newShowStartClient(cfg, opts...)
+}
+
+func newShowStartClient(cfg *config.Showstart, opts ...Option) *ShowStartClient {
+	logger := log.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
 
 	c := &ShowStartClient{
-		BashUrl: "https://wap.showstart.com/v3",
-		client: &http.Client{
-			Timeout: 20 * time.Second,
-			Transport: &http.Transport{
-				TLSHandshakeTimeout:   10 * time.Second,
-				ResponseHeaderTimeout: 15 * time.Second,
-				IdleConnTimeout:       30 * time.Second,
-				MaxIdleConns:          100,
-				MaxIdleConnsPerHost:   10,
-			},
-		},
+		BashUrl: DefaultBaseURL,
+		client:  defaultHTTPClient(),
+		retry:   DefaultRetryPolicy,
+		order:   DefaultOrderPolicy,
+		poll:    DefaultPollPolicy,
+		logger:  logger,
+		clock:   SystemClock,
+		signer:  util.DefaultSigner,
 		ClientHeaderConfig: &ClientHeaderConfig{
 			Sign:        cfg.Sign,
 			Token:       cfg.Token,
@@ -73,81 +94,75 @@ func NewShowStartClient(ctx context.Context, cfg *config.Showstart) ShowStartIfa
 			Cusut:       cfg.Sign,
 		},
 	}
+	if cfg.BaseURL != "" {
+		c.BashUrl = cfg.BaseURL
+	}
+	if cfg.SchemaDrift {
+		c.drift = NewDriftDetector(func(drifts []Drift) {
+			for _, d := range drifts {
+				logger.Warn("🧬接口返回结构变化", zap.String("path", d.Path), zap.String("field", d.Field), zap.String("kind", string(d.Kind)), zap.String("expected", d.Expected), zap.String("actual", d.Actual))
+			}
+		})
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	// 审计日志在 WithClock 之后创建，位于用户拦截器外层
+	if cfg.AuditFile != "" {
+		if f, err := os.OpenFile(cfg.AuditFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600); err != nil {
+			c.logger.Warn("⚠️打开审计日志失败", zap.String("file", cfg.AuditFile), zap.Error(err))
+		} else {
+			c.interceptors = append([]Interceptor{AuditInterceptor(f, c.clock)}, c.interceptors...)
+		}
+	}
+	// 录制与回放在 WithHTTPClient、WithTransport 之后包装，自定义的 http.Client 同样生效
+	switch {
+	case cfg.ReplayFile != "":
+		WithTransport(cassette.NewReplayer(cfg.ReplayFile))(c)
+	case cfg.RecordFile != "":
+		WithTransport(cassette.NewRecorder(cfg.RecordFile, c.client.Transport))(c)
+	}
+	if !c.limiterSet {
+		c.limiter = defaultRateLimiter(c.clock)
+	}
+	c.creds = newCredentialManager(c.fetchToken, c.clock)
+	builtin := []Interceptor{DebugLogInterceptor(c.logger)}
+	if c.limiter != nil {
+		builtin = append([]Interceptor{RateLimitInterceptor(c.limiter, c.logger)}, builtin...)
+	}
+	c.invoke = chainInterceptors(c.roundTrip, append(builtin, c.interceptors...))
 
 	return c
 }
 
+// Post 发送一次请求，不做重试；非 2xx 响应返回 *HTTPError
 func (c *ShowStartClient) Post(ctx context.Context, path string, body string) ([]byte, error) {
-	var lastErr error
-	backoff := 500 * time.Millisecond
-
-	for attempt := 0; attempt < 3; attempt++ {
-		req, err := c.NewRequest(ctx, "POST", path, body)
-		if err != nil {
-			return nil, err
-		}
-
-		res, err := c.client.Do(req)
-		if err != nil {
-			lastErr = err
-			if attempt < 2 && shouldRetry(err) {
-				log.Logger.Warn("请求失败，将重试", zap.String("path", path), zap.Int("attempt", attempt+1), zap.Error(err))
-				time.Sleep(backoff)
-				backoff *= 2
-				continue
-			}
-			return nil, err
-		}
-
-		data, readErr := io.ReadAll(res.Body)
-		res.Body.Close()
-		if readErr != nil {
-			lastErr = readErr
-			if attempt < 2 {
-				log.Logger.Warn("读取响应失败，将重试", zap.String("path", path), zap.Int("attempt", attempt+1), zap.Error(readErr))
-				time.Sleep(backoff)
-				backoff *= 2
-				continue
-			}
-			return nil, readErr
-		}
-
-		if res.StatusCode >= 500 {
-			lastErr = fmt.Errorf("http %d", res.StatusCode)
-			if attempt < 2 {
-				log.Logger.Warn("服务器返回 5xx，即将重试", zap.String("path", path), zap.Int("status", res.StatusCode))
-				time.Sleep(backoff)
-				backoff *= 2
-				continue
-			}
-			return nil, fmt.Errorf("http %d: %s", res.StatusCode, string(data))
-		}
-
-		if res.StatusCode >= 400 {
-			return nil, fmt.Errorf("http %d: %s", res.StatusCode, string(data))
-		}
-
-		return data, nil
+	req, err := c.NewRequest(ctx, "POST", path, body)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, lastErr
-}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
 
-func shouldRetry(err error) bool {
-	if err == nil {
-		return false
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
 	}
-	var netErr net.Error
-	if errors.As(err, &netErr) {
-		if netErr.Timeout() || netErr.Temporary() {
-			return true
-		}
+
+	if res.StatusCode >= 400 {
+		return nil, &HTTPError{Path: path, StatusCode: res.StatusCode, Body: string(data)}
 	}
-	return false
+
+	return data, nil
 }
 
 func (c *ShowStartClient) NewRequest(ctx context.Context, method, path string, body string) (*http.Request, error) {
 	traceId := util.GenerateTraceId(32)
+	creds := c.creds.Snapshot()
 	if need, ok := vars.EncryptPathMap[path]; ok && need {
 
 		// 加密
@@ -159,18 +174,6 @@ func (c *ShowStartClient) NewRequest(ctx context.Context, method, path string, b
 		body = fmt.Sprintf(`{"q":"%s"}`, encrypt)
 	}
 
-	crpsign := util.GenerateSign(&util.GenerateSignReq{
-		Path:      path,
-		Data:      body,
-		Cusat:     c.Cusat,
-		Sign:      c.Sign,
-		Cusit:     c.Cusit,
-		Cusid:     c.Cusid,
-		TraceId:   traceId,
-		Token:     c.Token,
-		Cterminal: c.Cterminal,
-	})
-
 	req, err := http.NewRequestWithContext(ctx, method, c.BashUrl+path, strings.NewReader(body))
 	if err != nil {
 		return nil, err
@@ -188,19 +191,21 @@ func (c *ShowStartClient) NewRequest(ctx context.Context, method, path string, b
 	req.Header.Add("cversion", c.Cversion)
 	req.Header.Add("st_flpv", c.StFlpv)
 	req.Header.Add("crtraceid", traceId)
-	req.Header.Add("crpsign", crpsign)
 
-	if c.Cusat == "" {
+	if creds.Cusat == "" {
 		req.Header.Add("cusat", "nil")
 	} else {
-		req.Header.Add("cusat", c.Cusat)
+		req.Header.Add("cusat", creds.Cusat)
 	}
 
-	if c.Cusit == "" {
+	if creds.Cusit == "" {
 		req.Header.Add("cusit", "nil")
 	} else {
-		req.Header.Add("cusit", c.Cusit)
+		req.Header.Add("cusit", creds.Cusit)
 	}
 
+	// 签名与 header 使用同一份取值，cversion、cterminal 变化时两者保持一致
+	req.Header.Add("crpsign", c.signer.Sign(util.SignReqFromHeader(path, body, req.Header)))
+
 	return req, nil
 }
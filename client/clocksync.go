@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultClockSamples 对时的默认采样次数
+const DefaultClockSamples = 8
+
+// ClockSample 一次对时采样：本地发送、接收时间与服务端 Date 头（精确到秒）
+type ClockSample struct {
+	Sent     time.Time
+	Received time.Time
+	Date     time.Time
+}
+
+// RTT 往返时间
+func (s ClockSample) RTT() time.Duration {
+	return s.Received.Sub(s.Sent)
+}
+
+// bounds 服务端生成 Date 时，本地时间在 [Sent, Received] 内，服务端时间在 [Date, Date+1s) 内，
+// 由此得到偏差的可能区间
+func (s ClockSample) bounds() (lo, hi time.Duration) {
+	return s.Date.Sub(s.Received), s.Date.Add(time.Second).Sub(s.Sent)
+}
+
+// ClockOffset 服务端时间相对本地时间的偏差估计：服务端时间 = 本地时间 + Offset
+type ClockOffset struct {
+	Offset time.Duration
+	// Uncertainty 误差范围，真实偏差在 Offset ± Uncertainty 内
+	Uncertainty time.Duration
+	// RTT 采样中最小的往返时间
+	RTT     time.Duration
+	Samples int
+}
+
+// Local 将服务端时间换算为本地时间
+func (o ClockOffset) Local(server time.Time) time.Time {
+	return server.Add(-o.Offset)
+}
+
+// Server 将本地时间换算为服务端时间
+func (o ClockOffset) Server(local time.Time) time.Time {
+	return local.Add(o.Offset)
+}
+
+// EstimateClockOffset 对各采样的偏差区间求交集，取交集中点为估计值。
+// 区间没有交集（例如服务端时间跳变）时，退回使用往返时间最短的一次采样
+func EstimateClockOffset(samples []ClockSample) (ClockOffset, error) {
+	if len(samples) == 0 {
+		return ClockOffset{}, errors.New("没有可用的对时采样")
+	}
+	lo, hi := samples[0].bounds()
+	best := samples[0]
+	for _, s := range samples[1:] {
+		l, h := s.bounds()
+		if l > lo {
+			lo = l
+		}
+		if h < hi {
+			hi = h
+		}
+		if s.RTT() < best.RTT() {
+			best = s
+		}
+	}
+	if lo > hi {
+		lo, hi = best.bounds()
+	}
+	return ClockOffset{
+		Offset:      lo + (hi-lo)/2,
+		Uncertainty: (hi - lo) / 2,
+		RTT:         best.RTT(),
+		Samples:     len(samples),
+	}, nil
+}
+
+// SampleClock 向接口地址发送一次 HEAD 请求，读取响应的 Date 头；发送与接收时间取自 WithClock 指定的时间来源
+func (c *ShowStartClient) SampleClock(ctx context.Context) (ClockSample, error) {
+	sent := c.clock.Now()
+	header, err := c.head(ctx)
+	if err != nil {
+		return ClockSample{}, err
+	}
+	received := c.clock.Now()
+
+	date, err := http.ParseTime(header.Get("Date"))
+	if err != nil {
+		return ClockSample{}, fmt.Errorf("解析服务端 Date 头失败：%w", err)
+	}
+	return ClockSample{Sent: sent, Received: received, Date: date}, nil
+}
+
+// SyncClock 采样 n 次估计服务端时钟偏差。Date 头只精确到秒，每次按当前估计选择发送时间，
+// 使请求恰好在服务端整秒附近到达，逐次缩小误差范围，精度受往返时间限制
+func (c *ShowStartClient) SyncClock(ctx context.Context, n int) (ClockOffset, error) {
+	if n <= 0 {
+		n = DefaultClockSamples
+	}
+	var samples []ClockSample
+	for i := 0; i < n; i++ {
+		if len(samples) > 0 {
+			est, _ := EstimateClockOffset(samples)
+			// ctx 结束时使用已有的采样
+			if err := c.sleepUntil(ctx, nextProbeTime(c.clock.Now(), est)); err != nil {
+				break
+			}
+		}
+		s, err := c.SampleClock(ctx)
+		if err != nil {
+			if len(samples) == 0 {
+				return ClockOffset{}, err
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		samples = append(samples, s)
+	}
+	return EstimateClockOffset(samples)
+}
+
+// nextProbeTime 下一次采样的本地发送时间：按当前估计，请求到达服务端时恰好是一个整秒
+func nextProbeTime(now time.Time, est ClockOffset) time.Time {
+	arrive := now.Add(est.Offset + est.RTT/2)
+	boundary := arrive.Truncate(time.Second).Add(time.Second)
+	// 至少间隔 50ms，避免连续请求
+	if boundary.Sub(arrive) < 50*time.Millisecond {
+		boundary = boundary.Add(time.Second)
+	}
+	return boundary.Add(-est.Offset - est.RTT/2)
+}
+
+// sleepUntil 按 client 的时间来源等待到 t
+func (c *ShowStartClient) sleepUntil(ctx context.Context, t time.Time) error {
+	d := t.Sub(c.clock.Now())
+	if d <= 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.clock.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/staparx/go_showstart/config"
+)
+
+// newClockServer 返回 Date 头为 clock 时间加 skew 的服务
+func newClockServer(clock *fakeClock, skew time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", clock.Now().Add(skew).UTC().Format(http.TimeFormat))
+	}))
+}
+
+func TestSyncClockUsesClock(t *testing.T) {
+	clock := newFakeClock()
+	skew := 1623 * time.Millisecond
+	srv := newClockServer(clock, skew)
+	defer srv.Close()
+	c := newShowStartClient(&config.Showstart{Token: "t", BaseURL: srv.URL}, WithClock(clock), WithRateLimiter(nil))
+
+	start := time.Now()
+	off, err := c.SyncClock(context.Background(), DefaultClockSamples)
+	if err != nil {
+		t.Fatalf("SyncClock: %v", err)
+	}
+	// 采样间隔由 fakeClock 推进，不应真实等待
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("SyncClock 耗时 %v", d)
+	}
+	if off.Samples != DefaultClockSamples {
+		t.Errorf("samples = %d, want %d", off.Samples, DefaultClockSamples)
+	}
+	if diff := off.Offset - skew; diff < -10*time.Millisecond || diff > 10*time.Millisecond {
+		t.Errorf("offset = %v, want %v ± 10ms", off.Offset, skew)
+	}
+	if off.Uncertainty > 10*time.Millisecond {
+		t.Errorf("uncertainty = %v", off.Uncertainty)
+	}
+	if got, want := off.Server(clock.Now()), clock.Now().Add(off.Offset); !got.Equal(want) {
+		t.Errorf("Server = %v, want %v", got, want)
+	}
+}
+
+func TestSampleClockUsesClock(t *testing.T) {
+	clock := newFakeClock()
+	srv := newClockServer(clock, 0)
+	defer srv.Close()
+	c := newShowStartClient(&config.Showstart{Token: "t", BaseURL: srv.URL}, WithClock(clock), WithRateLimiter(nil))
+
+	s, err := c.SampleClock(context.Background())
+	if err != nil {
+		t.Fatalf("SampleClock: %v", err)
+	}
+	if !s.Sent.Equal(clock.Now()) || s.RTT() != 0 || !s.Date.Equal(clock.Now()) {
+		t.Errorf("sample = %+v, want 时间取自 fakeClock %v", s, clock.Now())
+	}
+}
+
+func TestEstimateClockOffset(t *testing.T) {
+	if _, err := EstimateClockOffset(nil); err == nil {
+		t.Fatal("没有采样时应返回错误")
+	}
+	base := time.Date(2024, 8, 16, 20, 0, 0, 0, time.UTC)
+	// 偏差区间 [-100ms, 1s] 与 [200ms, 1.25s] 的交集为 [200ms, 1s]
+	samples := []ClockSample{
+		{Sent: base, Received: base.Add(100 * time.Millisecond), Date: base},
+		{Sent: base.Add(-200 * time.Millisecond), Received: base.Add(-150 * time.Millisecond), Date: base.Add(50 * time.Millisecond)},
+	}
+	off, err := EstimateClockOffset(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if off.Offset != 600*time.Millisecond || off.Uncertainty != 400*time.Millisecond || off.RTT != 50*time.Millisecond {
+		t.Errorf("offset = %+v", off)
+	}
+}
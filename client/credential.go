@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultRefreshAhead token 过期前提前刷新的时间
+const defaultRefreshAhead = time.Minute
+
+// credentialSnapshot 某一时刻的 token，同一个请求的签名和 header 使用同一份快照
+type credentialSnapshot struct {
+	Cusat string
+	Cusit string
+}
+
+// refreshCall 正在进行中的 token 刷新
+type refreshCall struct {
+	done chan struct{}
+	err  error
+	// canceled 发起刷新的调用方 ctx 已结束，err 不代表刷新本身的结果
+	canceled bool
+}
+
+// credentialManager 线程安全地保存 token 及其过期时间，并发刷新合并为一次请求
+type credentialManager struct {
+	mu           sync.Mutex
+	cusat        string
+	cusit        string
+	expireAt     time.Time
+	refreshedAt  time.Time
+	refreshAhead time.Duration
+	inflight     *refreshCall
+
+	fetch func(ctx context.Context) (*GetTokenResp, error)
+	clock Clock
+}
+
+func newCredentialManager(fetch func(ctx context.Context) (*GetTokenResp, error), clock Clock) *credentialManager {
+	return &credentialManager{
+		refreshAhead: defaultRefreshAhead,
+		fetch:        fetch,
+		clock:        clock,
+	}
+}
+
+// Snapshot 返回当前 token 的一致快照
+func (m *credentialManager) Snapshot() credentialSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return credentialSnapshot{Cusat: m.cusat, Cusit: m.cusit}
+}
+
+// ExpireAt token 过期时间，未知时为零值
+func (m *credentialManager) ExpireAt() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.expireAt
+}
+
+// Refresh 刷新 token；已有刷新在进行时等待其结果而不是重复请求。
+// 发起刷新的调用方 ctx 被取消时，仍在等待的调用方重新发起刷新
+func (m *credentialManager) Refresh(ctx context.Context) error {
+	for {
+		m.mu.Lock()
+		call := m.inflight
+		if call == nil {
+			call = &refreshCall{done: make(chan struct{})}
+			m.inflight = call
+			m.mu.Unlock()
+
+			call.err = m.doRefresh(ctx)
+			call.canceled = call.err != nil && ctx.Err() != nil
+
+			m.mu.Lock()
+			m.inflight = nil
+			m.mu.Unlock()
+			close(call.done)
+			return call.err
+		}
+		m.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-call.done:
+		}
+		if !call.canceled {
+			return call.err
+		}
+	}
+}
+
+// RefreshSince 仅当 since 之后没有成功刷新过时才刷新，避免多个并发请求同时遇到登录过期时重复刷新
+func (m *credentialManager) RefreshSince(ctx context.Context, since time.Time) error {
+	m.mu.Lock()
+	fresh := m.refreshedAt.After(since)
+	m.mu.Unlock()
+	if fresh {
+		return nil
+	}
+	return m.Refresh(ctx)
+}
+
+// EnsureFresh 在 token 即将过期时提前刷新
+func (m *credentialManager) EnsureFresh(ctx context.Context) error {
+	m.mu.Lock()
+	expireAt := m.expireAt
+	m.mu.Unlock()
+
+	if expireAt.IsZero() || m.clock.Now().Add(m.refreshAhead).Before(expireAt) {
+		return nil
+	}
+	return m.Refresh(ctx)
+}
+
+func (m *credentialManager) doRefresh(ctx context.Context) error {
+	resp, err := m.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := m.clock.Now()
+	expireAt := earliest(
+		expireTime(now, resp.Result.AccessToken.Expire),
+		expireTime(now, resp.Result.IDToken.Expire),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cusat = resp.Result.AccessToken.AccessToken
+	m.cusit = resp.Result.IDToken.IDToken
+	m.expireAt = expireAt
+	m.refreshedAt = now
+	return nil
+}
+
+// expireTime 将接口返回的 expire 转换为过期时间：兼容毫秒时间戳、秒时间戳以及剩余秒数
+func expireTime(now time.Time, expire int) time.Time {
+	v := int64(expire)
+	switch {
+	case v <= 0:
+		return time.Time{}
+	case v > 1e12:
+		return time.UnixMilli(v)
+	case v > 1e9:
+		return time.Unix(v, 0)
+	default:
+		return now.Add(time.Duration(v) * time.Second)
+	}
+}
+
+func earliest(a, b time.Time) time.Time {
+	switch {
+	case a.IsZero():
+		return b
+	case b.IsZero():
+		return a
+	case b.Before(a):
+		return b
+	default:
+		return a
+	}
+}
@@ -0,0 +1,229 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tokenFetcher 计数的 fetch，第 n 次返回 at-n/it-n，过期时间为 expire
+type tokenFetcher struct {
+	calls  int32
+	expire int
+	// block 不为 nil 时，fetch 等待 block 关闭或 ctx 结束
+	block chan struct{}
+}
+
+func (f *tokenFetcher) fetch(ctx context.Context) (*GetTokenResp, error) {
+	n := atomic.AddInt32(&f.calls, 1)
+	if f.block != nil {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-f.block:
+		}
+	}
+	resp := &GetTokenResp{}
+	resp.Result.AccessToken.AccessToken = fmt.Sprintf("at-%d", n)
+	resp.Result.AccessToken.Expire = f.expire
+	resp.Result.IDToken.IDToken = fmt.Sprintf("it-%d", n)
+	resp.Result.IDToken.Expire = f.expire
+	return resp, nil
+}
+
+func (f *tokenFetcher) count() int {
+	return int(atomic.LoadInt32(&f.calls))
+}
+
+// waitInflight 等待 m 上有刷新正在进行
+func waitInflight(t *testing.T, m *credentialManager) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		m.mu.Lock()
+		inflight := m.inflight != nil
+		m.mu.Unlock()
+		if inflight {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("没有进行中的刷新")
+}
+
+func TestRefreshConcurrentCallersFetchOnce(t *testing.T) {
+	f := &tokenFetcher{expire: 3600, block: make(chan struct{})}
+	m := newCredentialManager(f.fetch, newFakeClock())
+
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- m.Refresh(context.Background())
+		}()
+	}
+	waitInflight(t, m)
+	// 等其余调用方进入等待后再完成刷新
+	time.Sleep(20 * time.Millisecond)
+	close(f.block)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Refresh: %v", err)
+		}
+	}
+	if got := f.count(); got != 1 {
+		t.Errorf("fetch %d 次, want 1", got)
+	}
+	if snap := m.Snapshot(); snap.Cusat != "at-1" || snap.Cusit != "it-1" {
+		t.Errorf("snapshot = %+v", snap)
+	}
+}
+
+func TestRefreshSinceSkipsNewerRefresh(t *testing.T) {
+	clock := newFakeClock()
+	f := &tokenFetcher{expire: 3600}
+	m := newCredentialManager(f.fetch, clock)
+
+	sentAt := clock.Now()
+	clock.Advance(time.Millisecond)
+	if err := m.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// 请求发出后已有其他调用方刷新过，不再重复刷新
+	if err := m.RefreshSince(context.Background(), sentAt); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.count(); got != 1 {
+		t.Fatalf("fetch %d 次, want 1", got)
+	}
+
+	clock.Advance(time.Millisecond)
+	if err := m.RefreshSince(context.Background(), clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.count(); got != 2 {
+		t.Errorf("fetch %d 次, want 2", got)
+	}
+}
+
+func TestEnsureFreshUsesClock(t *testing.T) {
+	clock := newFakeClock()
+	f := &tokenFetcher{expire: 600}
+	m := newCredentialManager(f.fetch, clock)
+
+	// 未知过期时间时不刷新
+	if err := m.EnsureFresh(context.Background()); err != nil || f.count() != 0 {
+		t.Fatalf("err = %v, fetch %d 次", err, f.count())
+	}
+	if err := m.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := clock.Now().Add(600 * time.Second); !m.ExpireAt().Equal(want) {
+		t.Fatalf("expireAt = %v, want %v", m.ExpireAt(), want)
+	}
+
+	clock.Advance(600*time.Second - defaultRefreshAhead - time.Second)
+	if err := m.EnsureFresh(context.Background()); err != nil || f.count() != 1 {
+		t.Fatalf("距过期超过 refreshAhead 时不应刷新，fetch %d 次", f.count())
+	}
+	clock.Advance(2 * time.Second)
+	if err := m.EnsureFresh(context.Background()); err != nil || f.count() != 2 {
+		t.Fatalf("进入 refreshAhead 后应提前刷新，err = %v, fetch %d 次", err, f.count())
+	}
+	if snap := m.Snapshot(); snap.Cusat != "at-2" {
+		t.Errorf("snapshot = %+v", snap)
+	}
+}
+
+func TestRefreshLeaderCancelDoesNotWedgeWaiters(t *testing.T) {
+	f := &tokenFetcher{expire: 3600, block: make(chan struct{})}
+	m := newCredentialManager(f.fetch, newFakeClock())
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		leaderErr <- m.Refresh(leaderCtx)
+	}()
+	waitInflight(t, m)
+
+	waiterErr := make(chan error, 1)
+	go func() {
+		waiterErr <- m.Refresh(context.Background())
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("leader err = %v, want context.Canceled", err)
+	}
+	// 等待方重新发起刷新
+	waitInflight(t, m)
+	close(f.block)
+	select {
+	case err := <-waiterErr:
+		if err != nil {
+			t.Fatalf("waiter err = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("leader 取消后等待方一直阻塞")
+	}
+	if got := f.count(); got != 2 {
+		t.Errorf("fetch %d 次, want 2", got)
+	}
+	if snap := m.Snapshot(); snap.Cusat != "at-2" {
+		t.Errorf("snapshot = %+v", snap)
+	}
+}
+
+func TestRefreshWaiterCancel(t *testing.T) {
+	f := &tokenFetcher{expire: 3600, block: make(chan struct{})}
+	defer close(f.block)
+	m := newCredentialManager(f.fetch, newFakeClock())
+
+	go m.Refresh(context.Background())
+	waitInflight(t, m)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Refresh(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestExpireTime(t *testing.T) {
+	now := time.Date(2024, 8, 16, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		expire int
+		want   time.Time
+	}{
+		{"零值", 0, time.Time{}},
+		{"负数", -1, time.Time{}},
+		{"剩余秒数", 7200, now.Add(2 * time.Hour)},
+		{"秒时间戳", 1723816800, time.Unix(1723816800, 0)},
+		{"毫秒时间戳", 1723816800123, time.UnixMilli(1723816800123)},
+	}
+	for _, tt := range tests {
+		if got := expireTime(now, tt.expire); !got.Equal(tt.want) {
+			t.Errorf("%s: expireTime(%d) = %v, want %v", tt.name, tt.expire, got, tt.want)
+		}
+	}
+
+	a, b := now.Add(time.Hour), now.Add(2*time.Hour)
+	if got := earliest(a, b); !got.Equal(a) {
+		t.Errorf("earliest = %v", got)
+	}
+	if got := earliest(time.Time{}, b); !got.Equal(b) {
+		t.Errorf("earliest 应忽略零值, got %v", got)
+	}
+}
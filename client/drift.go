@@ -0,0 +1,237 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DriftKind 响应结构变化的类型
+type DriftKind string
+
+const (
+	// DriftNewField 响应中出现结构体未定义的字段
+	DriftNewField DriftKind = "new_field"
+	// DriftMissingField 结构体定义的字段未出现在响应中
+	DriftMissingField DriftKind = "missing_field"
+	// DriftTypeMismatch 字段的 JSON 类型与结构体不一致
+	DriftTypeMismatch DriftKind = "type_mismatch"
+)
+
+// Drift 一处响应结构与类型定义不一致的地方
+type Drift struct {
+	Path  string
+	Field string
+	Kind  DriftKind
+	// Expected 结构体中的 Go 类型，新字段时为空
+	Expected string
+	// Actual 响应中的 JSON 类型，缺失字段时为空
+	Actual string
+}
+
+// Signature 用于去重的特征，同一接口同一字段同一类变化只报告一次
+func (d Drift) Signature() string {
+	return strings.Join([]string{d.Path, d.Field, string(d.Kind), d.Expected, d.Actual}, "|")
+}
+
+func (d Drift) String() string {
+	switch d.Kind {
+	case DriftNewField:
+		return fmt.Sprintf("%s 新字段 %s（%s）", d.Path, d.Field, d.Actual)
+	case DriftMissingField:
+		return fmt.Sprintf("%s 缺少字段 %s（%s）", d.Path, d.Field, d.Expected)
+	default:
+		return fmt.Sprintf("%s 字段 %s 类型变化：期望 %s，实际 %s", d.Path, d.Field, d.Expected, d.Actual)
+	}
+}
+
+// DriftDetector 将每个响应与类型定义比对，首次出现的变化交给 handler
+type DriftDetector struct {
+	mu      sync.Mutex
+	seen    map[string]struct{}
+	handler func([]Drift)
+}
+
+func NewDriftDetector(handler func([]Drift)) *DriftDetector {
+	return &DriftDetector{seen: map[string]struct{}{}, handler: handler}
+}
+
+// Check 比对 path 的响应 data 与类型 typ，返回本进程内首次出现的变化
+func (d *DriftDetector) Check(path string, typ reflect.Type, data []byte) []Drift {
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil
+	}
+
+	found := map[string]Drift{}
+	walkDrift(path, "", typ, generic, found)
+
+	d.mu.Lock()
+	var fresh []Drift
+	for sig, drift := range found {
+		if _, ok := d.seen[sig]; ok {
+			continue
+		}
+		d.seen[sig] = struct{}{}
+		fresh = append(fresh, drift)
+	}
+	d.mu.Unlock()
+
+	sort.Slice(fresh, func(i, j int) bool { return fresh[i].Signature() < fresh[j].Signature() })
+	if len(fresh) > 0 && d.handler != nil {
+		d.handler(fresh)
+	}
+	return fresh
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+func walkDrift(path, field string, typ reflect.Type, v interface{}, found map[string]Drift) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if v == nil || typ.Kind() == reflect.Interface || reflect.PtrTo(typ).Implements(jsonUnmarshalerType) {
+		return
+	}
+
+	if actual, ok := jsonKindMatches(typ, v); !ok {
+		addDrift(found, Drift{Path: path, Field: fieldName(field), Kind: DriftTypeMismatch, Expected: typ.String(), Actual: actual})
+		return
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		obj := v.(map[string]interface{})
+		fields := jsonFields(typ)
+		matched := map[string]bool{}
+		for name, f := range fields {
+			key, value, ok := lookupKey(obj, name)
+			if !ok {
+				addDrift(found, Drift{Path: path, Field: joinField(field, name), Kind: DriftMissingField, Expected: f.Type.String()})
+				continue
+			}
+			matched[key] = true
+			walkDrift(path, joinField(field, name), f.Type, value, found)
+		}
+		for key, value := range obj {
+			if !matched[key] {
+				addDrift(found, Drift{Path: path, Field: joinField(field, key), Kind: DriftNewField, Actual: jsonKind(value)})
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for _, item := range v.([]interface{}) {
+			walkDrift(path, field+"[]", typ.Elem(), item, found)
+		}
+	case reflect.Map:
+		for _, item := range v.(map[string]interface{}) {
+			walkDrift(path, field+"{}", typ.Elem(), item, found)
+		}
+	}
+}
+
+func addDrift(found map[string]Drift, d Drift) {
+	found[d.Signature()] = d
+}
+
+// jsonFields 结构体的 JSON 字段，展开匿名嵌入的结构体
+func jsonFields(typ reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for k, v := range jsonFields(embedded) {
+					fields[k] = v
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+// lookupKey 与 encoding/json 一致，精确匹配失败时忽略大小写匹配
+func lookupKey(obj map[string]interface{}, name string) (string, interface{}, bool) {
+	if v, ok := obj[name]; ok {
+		return name, v, true
+	}
+	for k, v := range obj {
+		if strings.EqualFold(k, name) {
+			return k, v, true
+		}
+	}
+	return "", nil, false
+}
+
+func jsonKindMatches(typ reflect.Type, v interface{}) (string, bool) {
+	actual := jsonKind(v)
+	switch typ.Kind() {
+	case reflect.String:
+		return actual, actual == "string"
+	case reflect.Bool:
+		return actual, actual == "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return actual, actual == "number"
+	case reflect.Slice, reflect.Array:
+		return actual, actual == "array" || typ.Elem().Kind() == reflect.Uint8
+	case reflect.Struct, reflect.Map:
+		return actual, actual == "object"
+	}
+	return actual, true
+}
+
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func fieldName(field string) string {
+	if field == "" {
+		return "(root)"
+	}
+	return field
+}
@@ -0,0 +1,201 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/staparx/go_showstart/vars"
+)
+
+type driftBase struct {
+	State string `json:"state"`
+}
+
+type driftItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type driftResp struct {
+	*driftBase
+	ID     int             `json:"id"`
+	Title  string          `json:"title"`
+	Item   driftItem       `json:"item"`
+	Items  []*driftItem    `json:"items"`
+	Ptr    *driftItem      `json:"ptr"`
+	Kind   vars.TicketType `json:"kind"`
+	Any    interface{}     `json:"any"`
+	NoTag  string
+	Skip   string `json:"-"`
+	hidden string
+}
+
+// driftOK 与 driftResp 完全一致的响应
+const driftOK = `{"state":"1","id":1,"title":"t","item":{"name":"a","count":1},"items":[{"name":"b","count":2}],` +
+	`"ptr":{"name":"c","count":3},"kind":"1","any":[1,"x"],"NoTag":"n"}`
+
+var driftType = reflect.TypeOf(driftResp{})
+
+func checkDrift(t *testing.T, data string) []Drift {
+	t.Helper()
+	return NewDriftDetector(nil).Check("/test", driftType, []byte(data))
+}
+
+func TestDriftNone(t *testing.T) {
+	if drifts := checkDrift(t, driftOK); len(drifts) != 0 {
+		t.Fatalf("drifts = %v", drifts)
+	}
+	// null 与自定义 UnmarshalJSON 的字段不比较类型
+	if drifts := checkDrift(t, `{"state":"1","id":1,"title":"t","item":{"name":"a","count":1},"items":null,"ptr":null,"kind":2,"any":{},"NoTag":"n"}`); len(drifts) != 0 {
+		t.Fatalf("drifts = %v", drifts)
+	}
+	if drifts := checkDrift(t, "not json"); drifts != nil {
+		t.Fatalf("非 JSON 响应 drifts = %v", drifts)
+	}
+}
+
+func TestDriftKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []Drift
+	}{
+		{
+			name: "新字段",
+			data: `{"state":"1","id":1,"title":"t","item":{"name":"a","count":1,"extra":true},"items":[],"ptr":null,"kind":1,"any":1,"NoTag":"n","newField":"x"}`,
+			want: []Drift{
+				{Path: "/test", Field: "item.extra", Kind: DriftNewField, Actual: "bool"},
+				{Path: "/test", Field: "newField", Kind: DriftNewField, Actual: "string"},
+			},
+		},
+		{
+			name: "缺少字段",
+			data: `{"state":"1","id":1,"item":{"name":"a"},"items":[],"ptr":null,"kind":1,"any":1,"NoTag":"n"}`,
+			want: []Drift{
+				{Path: "/test", Field: "item.count", Kind: DriftMissingField, Expected: "int"},
+				{Path: "/test", Field: "title", Kind: DriftMissingField, Expected: "string"},
+			},
+		},
+		{
+			name: "数字与字符串",
+			data: `{"state":1,"id":"1","title":"t","item":{"name":"a","count":"1"},"items":[],"ptr":null,"kind":1,"any":1,"NoTag":"n"}`,
+			want: []Drift{
+				{Path: "/test", Field: "id", Kind: DriftTypeMismatch, Expected: "int", Actual: "string"},
+				{Path: "/test", Field: "item.count", Kind: DriftTypeMismatch, Expected: "int", Actual: "string"},
+				{Path: "/test", Field: "state", Kind: DriftTypeMismatch, Expected: "string", Actual: "number"},
+			},
+		},
+		{
+			name: "对象与数组",
+			data: `{"state":"1","id":1,"title":"t","item":[],"items":{"name":"b"},"ptr":[{"name":"c"}],"kind":1,"any":1,"NoTag":"n"}`,
+			want: []Drift{
+				// 按 Signature 排序
+				{Path: "/test", Field: "items", Kind: DriftTypeMismatch, Expected: "[]*client.driftItem", Actual: "object"},
+				{Path: "/test", Field: "item", Kind: DriftTypeMismatch, Expected: "client.driftItem", Actual: "array"},
+				{Path: "/test", Field: "ptr", Kind: DriftTypeMismatch, Expected: "client.driftItem", Actual: "array"},
+			},
+		},
+		{
+			name: "数组元素",
+			data: `{"state":"1","id":1,"title":"t","item":{"name":"a","count":1},"items":[{"name":"b","count":2},{"name":"c","count":3,"sku":"x"},{"name":3,"count":1}],"ptr":null,"kind":1,"any":1,"NoTag":"n"}`,
+			want: []Drift{
+				{Path: "/test", Field: "items[].name", Kind: DriftTypeMismatch, Expected: "string", Actual: "number"},
+				{Path: "/test", Field: "items[].sku", Kind: DriftNewField, Actual: "string"},
+			},
+		},
+		{
+			name: "根节点类型变化",
+			data: `[1,2]`,
+			want: []Drift{
+				{Path: "/test", Field: "(root)", Kind: DriftTypeMismatch, Expected: "client.driftResp", Actual: "array"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkDrift(t, tt.data)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("drifts = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDriftEmbeddedPointerFlattened(t *testing.T) {
+	// 匿名嵌入的 *driftBase 字段展开到外层，缺失时报告为 state 而不是 driftBase
+	got := checkDrift(t, `{"id":1,"title":"t","item":{"name":"a","count":1},"items":[],"ptr":null,"kind":1,"any":1,"NoTag":"n"}`)
+	want := []Drift{{Path: "/test", Field: "state", Kind: DriftMissingField, Expected: "string"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("drifts = %+v\nwant %+v", got, want)
+	}
+
+	fields := jsonFields(driftType)
+	for _, name := range []string{"state", "id", "NoTag"} {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("jsonFields 缺少 %s", name)
+		}
+	}
+	for _, name := range []string{"driftBase", "Skip", "-", "hidden"} {
+		if _, ok := fields[name]; ok {
+			t.Errorf("jsonFields 不应包含 %s", name)
+		}
+	}
+}
+
+func TestDriftCaseInsensitiveKey(t *testing.T) {
+	// 与 encoding/json 一致，大小写不同的 key 视为同一字段
+	if drifts := checkDrift(t, `{"State":"1","ID":1,"Title":"t","item":{"NAME":"a","count":1},"items":[],"ptr":null,"kind":1,"any":1,"notag":"n"}`); len(drifts) != 0 {
+		t.Fatalf("drifts = %v", drifts)
+	}
+
+	obj := map[string]interface{}{"Title": "t"}
+	if key, v, ok := lookupKey(obj, "title"); !ok || key != "Title" || v != "t" {
+		t.Errorf("lookupKey = %q, %v, %v", key, v, ok)
+	}
+	if _, _, ok := lookupKey(obj, "name"); ok {
+		t.Error("lookupKey 不应匹配不存在的字段")
+	}
+}
+
+func TestDriftReportedOnce(t *testing.T) {
+	var calls [][]Drift
+	d := NewDriftDetector(func(drifts []Drift) {
+		calls = append(calls, drifts)
+	})
+	data := `{"state":"1","id":"1","title":"t","item":{"name":"a","count":1},"items":[],"ptr":null,"kind":1,"any":1,"NoTag":"n","extra":1}`
+
+	first := d.Check("/test", driftType, []byte(data))
+	if len(first) != 2 || len(calls) != 1 {
+		t.Fatalf("第一次 drifts = %v, handler 调用 %d 次", first, len(calls))
+	}
+	// 同样的变化不再报告，也不再调用 handler
+	if again := d.Check("/test", driftType, []byte(data)); len(again) != 0 || len(calls) != 1 {
+		t.Fatalf("重复 drifts = %v, handler 调用 %d 次", again, len(calls))
+	}
+	// 其他接口的同一字段是不同的特征
+	if other := d.Check("/other", driftType, []byte(data)); len(other) != 2 || len(calls) != 2 {
+		t.Fatalf("/other drifts = %v, handler 调用 %d 次", other, len(calls))
+	}
+	// 只报告新出现的变化
+	more := d.Check("/test", driftType, []byte(`{"state":"1","id":"1","title":"t","item":{"name":"a","count":1},"items":[],"ptr":null,"kind":1,"any":1,"NoTag":"n","extra":1,"more":[]}`))
+	want := []Drift{{Path: "/test", Field: "more", Kind: DriftNewField, Actual: "array"}}
+	if !reflect.DeepEqual(more, want) || len(calls) != 3 {
+		t.Fatalf("drifts = %+v, handler 调用 %d 次", more, len(calls))
+	}
+}
+
+func TestDriftString(t *testing.T) {
+	tests := []struct {
+		d    Drift
+		want string
+	}{
+		{Drift{Path: "/p", Field: "f", Kind: DriftNewField, Actual: "number"}, "/p 新字段 f（number）"},
+		{Drift{Path: "/p", Field: "f", Kind: DriftMissingField, Expected: "int"}, "/p 缺少字段 f（int）"},
+		{Drift{Path: "/p", Field: "f", Kind: DriftTypeMismatch, Expected: "int", Actual: "string"}, "/p 字段 f 类型变化：期望 int，实际 string"},
+	}
+	for _, tt := range tests {
+		if got := tt.d.String(); got != tt.want {
+			t.Errorf("String = %q, want %q", got, tt.want)
+		}
+	}
+}
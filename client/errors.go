@@ -0,0 +1,117 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrPending 服务端返回 result 为 "pending"，表示订单仍在处理中
+var ErrPending = errors.New("showstart: result pending")
+
+// APIError 秀动接口返回的业务错误
+type APIError struct {
+	Path    string
+	State   string
+	Status  int
+	Msg     string
+	TraceID string
+}
+
+func (e *APIError) Error() string {
+	if e.Msg != "" {
+		return e.Msg
+	}
+	return fmt.Sprintf("showstart %s: state=%s status=%d traceId=%s", e.Path, e.State, e.Status, e.TraceID)
+}
+
+// TokenExpired 是否为登录过期
+func (e *APIError) TokenExpired() bool {
+	return e.State == "token-expire-at" || e.Msg == "登录过期了，请重新登录！"
+}
+
+// throttleMsgs 秀动限流提示中的关键字
+var throttleMsgs = []string{"小手指点得太快啦", "休息一下"}
+
+// Throttled 是否为限流提示
+func (e *APIError) Throttled() bool {
+	return containsAny(e.Msg, throttleMsgs)
+}
+
+// soldOutMsgs 售罄提示中的关键字
+var soldOutMsgs = []string{"售罄", "已售完", "库存不足"}
+
+// limitMsgs 达到限购提示中的关键字
+var limitMsgs = []string{"限购", "超过购买数量", "购买数量已达上限"}
+
+// SoldOut 是否为售罄提示
+func (e *APIError) SoldOut() bool {
+	return containsAny(e.Msg, soldOutMsgs)
+}
+
+// LimitReached 是否为达到限购提示
+func (e *APIError) LimitReached() bool {
+	return containsAny(e.Msg, limitMsgs)
+}
+
+func containsAny(s string, keywords []string) bool {
+	for _, k := range keywords {
+		if strings.Contains(s, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func newAPIError(path string, resp *ShowStartCommonResp) *APIError {
+	if resp == nil {
+		return &APIError{Path: path, Msg: "响应缺少 state 字段"}
+	}
+	return &APIError{
+		Path:    path,
+		State:   resp.State,
+		Status:  resp.Status,
+		Msg:     resp.Msg,
+		TraceID: resp.TraceID,
+	}
+}
+
+// HTTPError 非 2xx 的 HTTP 响应
+type HTTPError struct {
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("http %d: %s", e.StatusCode, e.Body)
+}
+
+// IsTokenExpired 判断错误是否为登录过期
+func IsTokenExpired(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.TokenExpired()
+}
+
+// IsThrottled 判断错误是否为服务端限流（"小手指点得太快啦，休息一下" 或 HTTP 429）
+func IsThrottled(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Throttled()
+	}
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests
+}
+
+// IsSoldOut 判断错误是否为票档售罄
+func IsSoldOut(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.SoldOut()
+}
+
+// IsLimitReached 判断错误是否为超过限购数量
+func IsLimitReached(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.LimitReached()
+}
@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/staparx/go_showstart/util"
+	"go.uber.org/zap"
+)
+
+// Call 一次接口请求，拦截器在 next 返回后可读取 Response、Latency 与 Err
+type Call struct {
+	Path string
+	// Body 加密前的明文请求体
+	Body string
+	// Attempt 第几次尝试，从 1 开始
+	Attempt  int
+	Response []byte
+	Latency  time.Duration
+	Err      error
+}
+
+// Invoker 执行一次请求
+type Invoker func(ctx context.Context, call *Call) error
+
+// Interceptor 包裹每次请求，按添加顺序由外向内执行
+type Interceptor func(ctx context.Context, call *Call, next Invoker) error
+
+// chainInterceptors 将拦截器串联到 invoker 外层
+func chainInterceptors(invoker Invoker, interceptors []Interceptor) Invoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(ctx context.Context, call *Call) error {
+			return interceptor(ctx, call, next)
+		}
+	}
+	return invoker
+}
+
+// DebugLogInterceptor 将请求与响应脱敏后写入 Debug 日志
+func DebugLogInterceptor(logger *zap.Logger) Interceptor {
+	return func(ctx context.Context, call *Call, next Invoker) error {
+		err := next(ctx, call)
+		if ce := logger.Check(zap.DebugLevel, call.Path); ce != nil {
+			ce.Write(
+				zap.Int("attempt", call.Attempt),
+				zap.Duration("latency", call.Latency),
+				zap.ByteString("request", util.RedactJSON([]byte(call.Body))),
+				zap.ByteString("response", util.RedactJSON(call.Response)),
+				zap.Error(err),
+			)
+		}
+		return err
+	}
+}
+
+// PathStats 单个接口的统计
+type PathStats struct {
+	Path         string
+	Count        int
+	Errors       int
+	TotalLatency time.Duration
+	MaxLatency   time.Duration
+}
+
+// AvgLatency 平均耗时
+func (s PathStats) AvgLatency() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Count)
+}
+
+// Metrics 按接口统计请求次数、失败次数与耗时
+type Metrics struct {
+	mu    sync.Mutex
+	paths map[string]*PathStats
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{paths: map[string]*PathStats{}}
+}
+
+// Snapshot 按路径排序返回当前统计
+func (m *Metrics) Snapshot() []PathStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := make([]PathStats, 0, len(m.paths))
+	for _, s := range m.paths {
+		res = append(res, *s)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Path < res[j].Path })
+	return res
+}
+
+func (m *Metrics) observe(call *Call) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.paths[call.Path]
+	if !ok {
+		s = &PathStats{Path: call.Path}
+		m.paths[call.Path] = s
+	}
+	s.Count++
+	if call.Err != nil {
+		s.Errors++
+	}
+	s.TotalLatency += call.Latency
+	if call.Latency > s.MaxLatency {
+		s.MaxLatency = call.Latency
+	}
+}
+
+// MetricsInterceptor 将每次请求的耗时与结果记录到 m
+func MetricsInterceptor(m *Metrics) Interceptor {
+	return func(ctx context.Context, call *Call, next Invoker) error {
+		err := next(ctx, call)
+		m.observe(call)
+		return err
+	}
+}
+
+// auditRecord 审计日志中的一条记录
+type auditRecord struct {
+	Time      time.Time       `json:"time"`
+	Path      string          `json:"path"`
+	Attempt   int             `json:"attempt"`
+	LatencyMs int64           `json:"latencyMs"`
+	Request   json.RawMessage `json:"request,omitempty"`
+	State     string          `json:"state,omitempty"`
+	Msg       string          `json:"msg,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// AuditInterceptor 以 JSON Lines 格式记录每次请求的脱敏请求体、返回状态与错误；
+// 记录时间取自 clock，应与 client 的 WithClock 一致，为 nil 时使用 SystemClock
+func AuditInterceptor(w io.Writer, clock Clock) Interceptor {
+	if clock == nil {
+		clock = SystemClock
+	}
+	var mu sync.Mutex
+	return func(ctx context.Context, call *Call, next Invoker) error {
+		start := clock.Now()
+		err := next(ctx, call)
+
+		record := auditRecord{
+			Time:      start,
+			Path:      call.Path,
+			Attempt:   call.Attempt,
+			LatencyMs: call.Latency.Milliseconds(),
+		}
+		if body := util.RedactJSON([]byte(call.Body)); json.Valid(body) {
+			record.Request = body
+		}
+		var common ShowStartCommonResp
+		if json.Unmarshal(call.Response, &common) == nil {
+			record.State = common.State
+			record.Msg = common.Msg
+		}
+		if err != nil {
+			record.Error = err.Error()
+		}
+
+		if data, marshalErr := json.Marshal(record); marshalErr == nil {
+			mu.Lock()
+			_, _ = w.Write(append(data, '\n'))
+			mu.Unlock()
+		}
+		return err
+	}
+}
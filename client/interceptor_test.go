@@ -0,0 +1,247 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/staparx/go_showstart/client/showstarttest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// eventLog 按发生顺序记录拦截器链中的事件
+type eventLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *eventLog) add(e string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, e)
+}
+
+func (l *eventLog) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = nil
+}
+
+func (l *eventLog) list() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.events...)
+}
+
+// eventClock 记录限流器的等待
+type eventClock struct {
+	*fakeClock
+	log *eventLog
+}
+
+func (c eventClock) After(d time.Duration) <-chan time.Time {
+	c.log.add("limit")
+	return c.fakeClock.After(d)
+}
+
+// eventCore 记录日志的级别与内容
+type eventCore struct {
+	log *eventLog
+}
+
+func (c eventCore) Enabled(zapcore.Level) bool        { return true }
+func (c eventCore) With([]zapcore.Field) zapcore.Core { return c }
+func (c eventCore) Sync() error                       { return nil }
+
+func (c eventCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c eventCore) Write(ent zapcore.Entry, _ []zapcore.Field) error {
+	c.log.add(ent.Level.String() + " " + ent.Message)
+	return nil
+}
+
+// eventTransport 记录真正发出的 HTTP 请求
+type eventTransport struct {
+	log *eventLog
+}
+
+func (t eventTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.log.add("http")
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func eventInterceptor(log *eventLog, name string) Interceptor {
+	return func(ctx context.Context, call *Call, next Invoker) error {
+		log.add(name + ">")
+		err := next(ctx, call)
+		log.add(name + "<")
+		return err
+	}
+}
+
+func TestInterceptorChainOrder(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+
+	events := &eventLog{}
+	clock := eventClock{newFakeClock(), events}
+	// 查询类接口每秒 1 个请求，第二次请求需要等待令牌
+	limiter := NewRateLimiter(LimiterConfig{Families: map[EndpointFamily]BucketConfig{FamilyQuery: {Rate: 1, Burst: 1, MinRate: 0.5}}}, clock)
+	c := newTestClient(t, s,
+		WithClock(clock),
+		WithRateLimiter(limiter),
+		WithLogger(zap.New(eventCore{events})),
+		WithTransport(eventTransport{events}),
+		WithInterceptors(eventInterceptor(events, "A"), eventInterceptor(events, "B")),
+	)
+	ctx := context.Background()
+	if _, err := c.ActivityDetail(ctx, s.Fixture().ActivityID); err != nil {
+		t.Fatalf("ActivityDetail: %v", err)
+	}
+
+	events.reset()
+	s.SetThrottle("/wap/activity/details", 1)
+	if _, err := c.ActivityDetail(ctx, s.Fixture().ActivityID); !IsThrottled(err) {
+		t.Fatalf("err = %v, want 限流", err)
+	}
+
+	// 限流器 → Debug 日志 → 用户拦截器（按添加顺序）→ 发送请求
+	want := []string{
+		"limit",
+		"A>", "B>", "http", "B<", "A<",
+		"debug /wap/activity/details",
+		"warn 🐢触发限流，降低请求速率",
+	}
+	if got := events.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %q\nwant %q", got, want)
+	}
+}
+
+func TestInterceptorSeesResponse(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+
+	var seen *Call
+	c := newTestClient(t, s, WithInterceptors(func(ctx context.Context, call *Call, next Invoker) error {
+		err := next(ctx, call)
+		copied := *call
+		seen = &copied
+		return err
+	}))
+	if _, err := c.ActivityDetail(context.Background(), s.Fixture().ActivityID); err != nil {
+		t.Fatal(err)
+	}
+	if seen == nil || seen.Path != "/wap/activity/details" || seen.Attempt != 1 || !strings.Contains(string(seen.Response), s.Fixture().ActivityName) {
+		t.Fatalf("call = %+v", seen)
+	}
+	// Body 为加密前的明文
+	if !strings.Contains(seen.Body, `"activityId"`) {
+		t.Errorf("body = %s", seen.Body)
+	}
+}
+
+func TestMetricsInterceptor(t *testing.T) {
+	m := NewMetrics()
+	invoke := chainInterceptors(func(ctx context.Context, call *Call) error {
+		call.Latency = time.Duration(call.Attempt) * 100 * time.Millisecond
+		if call.Attempt == 3 {
+			call.Err = errors.New("boom")
+			return call.Err
+		}
+		return nil
+	}, []Interceptor{MetricsInterceptor(m)})
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		_ = invoke(context.Background(), &Call{Path: "/nj/order/order", Attempt: attempt})
+	}
+	_ = invoke(context.Background(), &Call{Path: "/waf/gettoken", Attempt: 1})
+
+	want := []PathStats{
+		{Path: "/nj/order/order", Count: 3, Errors: 1, TotalLatency: 600 * time.Millisecond, MaxLatency: 300 * time.Millisecond},
+		{Path: "/waf/gettoken", Count: 1, TotalLatency: 100 * time.Millisecond, MaxLatency: 100 * time.Millisecond},
+	}
+	got := m.Snapshot()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("snapshot = %+v\nwant %+v", got, want)
+	}
+	if avg := got[0].AvgLatency(); avg != 200*time.Millisecond {
+		t.Errorf("avg = %v", avg)
+	}
+	if avg := (PathStats{}).AvgLatency(); avg != 0 {
+		t.Errorf("空统计 avg = %v", avg)
+	}
+}
+
+func TestAuditInterceptor(t *testing.T) {
+	clock := newFakeClock()
+	var buf bytes.Buffer
+	invoke := chainInterceptors(func(ctx context.Context, call *Call) error {
+		call.Latency = 42 * time.Millisecond
+		call.Response = []byte(`{"state":"0","msg":"小手指点得太快啦，休息一下","result":null}`)
+		return &APIError{Path: call.Path, State: "0", Msg: "小手指点得太快啦，休息一下"}
+	}, []Interceptor{AuditInterceptor(&buf, clock)})
+
+	body := `{"skuId":"ticket-388","sign":"f65f2dbcd7387b2376bcefe13754856b","telephone":"13800000000"}`
+	if err := invoke(context.Background(), &Call{Path: "/nj/order/order", Body: body, Attempt: 2}); err == nil {
+		t.Fatal("应返回错误")
+	}
+	_ = invoke(context.Background(), &Call{Path: "/nj/order/order", Body: "not json", Attempt: 1})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("审计日志 %d 行:\n%s", len(lines), buf.String())
+	}
+	var record auditRecord
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if !record.Time.Equal(clock.Now()) || record.Path != "/nj/order/order" || record.Attempt != 2 || record.LatencyMs != 42 ||
+		record.State != "0" || record.Msg != "小手指点得太快啦，休息一下" || record.Error == "" {
+		t.Errorf("record = %+v", record)
+	}
+	if strings.Contains(lines[0], "f65f2dbcd7387b2376bcefe13754856b") || strings.Contains(lines[0], "13800000000") || !strings.Contains(lines[0], "ticket-388") {
+		t.Errorf("请求体未脱敏: %s", lines[0])
+	}
+	// 非 JSON 请求体不写入
+	if strings.Contains(lines[1], "not json") || strings.Contains(lines[1], `"request"`) {
+		t.Errorf("record = %s", lines[1])
+	}
+}
+
+func TestAuditFileUsesClientClock(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	file := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	clock := newFakeClock()
+	cfg := testShowstartConfig(s)
+	cfg.AuditFile = file
+	c := newShowStartClient(cfg, WithClock(clock), WithRateLimiter(nil))
+	if err := c.GetToken(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record auditRecord
+	if err := json.Unmarshal(bytes.TrimSpace(data), &record); err != nil {
+		t.Fatalf("审计日志 %s: %v", data, err)
+	}
+	if !record.Time.Equal(clock.Now()) || record.Path != getTokenPath || record.State != "1" {
+		t.Errorf("record = %+v, want time %v", record, clock.Now())
+	}
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/staparx/go_showstart/util"
+	"go.uber.org/zap"
+)
+
+// DefaultBaseURL 秀动 wap 接口地址
+const DefaultBaseURL = "https://wap.showstart.com/v3"
+
+// Clock 时间来源，便于测试或嵌入时替换
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SystemClock 使用本机时间的 Clock
+var SystemClock Clock = systemClock{}
+
+// Option ShowStartClient 的可选配置
+type Option func(*ShowStartClient)
+
+// WithBaseURL 替换接口地址，例如指向本地测试服务
+func WithBaseURL(baseURL string) Option {
+	return func(c *ShowStartClient) {
+		if baseURL != "" {
+			c.BashUrl = baseURL
+		}
+	}
+}
+
+// WithHTTPClient 使用自定义的 http.Client
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *ShowStartClient) {
+		if hc != nil {
+			c.client = hc
+		}
+	}
+}
+
+// WithTransport 替换底层 RoundTripper，保留默认的超时设置
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *ShowStartClient) {
+		if rt == nil {
+			return
+		}
+		hc := *c.client
+		hc.Transport = rt
+		c.client = &hc
+	}
+}
+
+// WithLogger 使用自定义的日志
+func WithLogger(logger *zap.Logger) Option {
+	return func(c *ShowStartClient) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// WithClock 使用自定义的时间来源；未指定 WithRateLimiter 时，默认限流器也使用该时间来源
+func WithClock(clock Clock) Option {
+	return func(c *ShowStartClient) {
+		if clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+// WithSigner 替换 crpsign 的签名方式
+func WithSigner(signer util.Signer) Option {
+	return func(c *ShowStartClient) {
+		if signer != nil {
+			c.signer = signer
+		}
+	}
+}
+
+// WithInterceptors 追加请求拦截器，可用于日志、监控与审计
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(c *ShowStartClient) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// WithRateLimiter 使用指定的限流器，多个 client 传入同一个实例即可共享额度；传入 nil 关闭限流
+func WithRateLimiter(l *RateLimiter) Option {
+	return func(c *ShowStartClient) {
+		c.limiter = l
+		c.limiterSet = true
+	}
+}
+
+// WithDriftDetector 检查每个响应与类型定义的差异，传入 nil 关闭检查
+func WithDriftDetector(d *DriftDetector) Option {
+	return func(c *ShowStartClient) {
+		c.drift = d
+	}
+}
+
+// WithRetryPolicy 替换普通接口的重试策略
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *ShowStartClient) {
+		c.retry = policy
+	}
+}
+
+// WithOrderPolicy 替换提交订单的重试策略
+func WithOrderPolicy(policy RetryPolicy) Option {
+	return func(c *ShowStartClient) {
+		c.order = policy
+	}
+}
+
+// WithPollPolicy 替换订单结果轮询的重试策略
+func WithPollPolicy(policy RetryPolicy) Option {
+	return func(c *ShowStartClient) {
+		c.poll = policy
+	}
+}
+
+// defaultHTTPClient 默认的 http.Client，代理读取 HTTP_PROXY/HTTPS_PROXY 环境变量
+func defaultHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 20 * time.Second,
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 15 * time.Second,
+			IdleConnTimeout:       30 * time.Second,
+			MaxIdleConns:          100,
+			// 开售前按下单协程数预热连接，需保留足够的空闲连接
+			MaxIdleConnsPerHost: 64,
+		},
+	}
+}
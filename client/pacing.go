@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SleepHint 服务端在下单返回中给出的节奏建议
+type SleepHint struct {
+	// Delay 两次下单之间至少间隔的时间
+	Delay time.Duration
+	// Until 建议的有效期，到期后恢复自身节奏
+	Until time.Time
+}
+
+// SleepHint 解析 result 中的 sleep 与 sleepExipre。
+// sleep 不小于 100 时按毫秒处理，否则按秒处理；sleepExipre 可以是毫秒/秒时间戳或秒数，
+// 缺省时建议只作用于下一次下单。sleep 不大于 0 或建议已过期时返回 false
+func (r *OrderResp) SleepHint(now time.Time) (SleepHint, bool) {
+	if r == nil || r.Result.Sleep <= 0 {
+		return SleepHint{}, false
+	}
+	var delay time.Duration
+	if r.Result.Sleep >= 100 {
+		delay = time.Duration(r.Result.Sleep * float64(time.Millisecond))
+	} else {
+		delay = time.Duration(r.Result.Sleep * float64(time.Second))
+	}
+
+	until := expireTime(now, int(r.Result.SleepExipre))
+	if until.IsZero() {
+		until = now.Add(delay)
+	}
+	if !until.After(now) {
+		return SleepHint{}, false
+	}
+	return SleepHint{Delay: delay, Until: until}, true
+}
+
+// PacerStats 服务端节奏建议的执行情况
+type PacerStats struct {
+	// Applied 收到并采纳的建议次数
+	Applied int
+	// Waits 因建议而等待的次数
+	Waits int
+	// Waited 因建议累计等待的时间
+	Waited time.Duration
+}
+
+// OrderPacer 在多个下单协程间共享服务端的节奏建议
+type OrderPacer struct {
+	clock    Clock
+	mu       sync.Mutex
+	interval time.Duration
+	until    time.Time
+	next     time.Time
+	stats    PacerStats
+}
+
+func NewOrderPacer(clock Clock) *OrderPacer {
+	if clock == nil {
+		clock = SystemClock
+	}
+	return &OrderPacer{clock: clock}
+}
+
+// Apply 采纳一次建议，下一次下单最早在 Delay 之后
+func (p *OrderPacer) Apply(hint SleepHint) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.clock.Now()
+	p.interval = hint.Delay
+	p.until = hint.Until
+	if next := now.Add(hint.Delay); next.After(p.next) {
+		p.next = next
+	}
+	p.stats.Applied++
+}
+
+// Wait 建议有效期内按间隔为调用方分配下单时间并等待，返回实际等待的时间
+func (p *OrderPacer) Wait(ctx context.Context) (time.Duration, error) {
+	p.mu.Lock()
+	now := p.clock.Now()
+	if p.interval <= 0 || !now.Before(p.until) {
+		p.mu.Unlock()
+		return 0, nil
+	}
+	slot := p.next
+	if slot.Before(now) {
+		slot = now
+	}
+	p.next = slot.Add(p.interval)
+	d := slot.Sub(now)
+	if d > 0 {
+		p.stats.Waits++
+		p.stats.Waited += d
+	}
+	p.mu.Unlock()
+
+	if d <= 0 {
+		return 0, nil
+	}
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-p.clock.After(d):
+		return d, nil
+	}
+}
+
+// Stats 当前统计
+func (p *OrderPacer) Stats() PacerStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stats
+}
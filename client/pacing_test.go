@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSleepHint(t *testing.T) {
+	now := time.Date(2024, 8, 16, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		sleep       float64
+		sleepExpire float64
+		wantOK      bool
+		wantDelay   time.Duration
+		wantUntil   time.Time
+	}{
+		{name: "无建议", sleep: 0, wantOK: false},
+		{name: "负数", sleep: -1, wantOK: false},
+		{name: "小于 100 按秒", sleep: 2, wantOK: true, wantDelay: 2 * time.Second, wantUntil: now.Add(2 * time.Second)},
+		{name: "小数秒", sleep: 0.5, wantOK: true, wantDelay: 500 * time.Millisecond, wantUntil: now.Add(500 * time.Millisecond)},
+		{name: "99 仍按秒", sleep: 99, wantOK: true, wantDelay: 99 * time.Second, wantUntil: now.Add(99 * time.Second)},
+		{name: "100 按毫秒", sleep: 100, wantOK: true, wantDelay: 100 * time.Millisecond, wantUntil: now.Add(100 * time.Millisecond)},
+		{name: "毫秒", sleep: 300, wantOK: true, wantDelay: 300 * time.Millisecond, wantUntil: now.Add(300 * time.Millisecond)},
+		{name: "有效期为秒数", sleep: 300, sleepExpire: 10, wantOK: true, wantDelay: 300 * time.Millisecond, wantUntil: now.Add(10 * time.Second)},
+		{name: "有效期为秒时间戳", sleep: 1, sleepExpire: float64(now.Add(time.Minute).Unix()), wantOK: true, wantDelay: time.Second, wantUntil: now.Add(time.Minute)},
+		{name: "有效期为毫秒时间戳", sleep: 1, sleepExpire: float64(now.Add(time.Minute).UnixMilli()), wantOK: true, wantDelay: time.Second, wantUntil: now.Add(time.Minute)},
+		{name: "已过期", sleep: 1, sleepExpire: float64(now.Add(-time.Minute).Unix()), wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &OrderResp{}
+			resp.Result.Sleep = tt.sleep
+			resp.Result.SleepExipre = tt.sleepExpire
+			hint, ok := resp.SleepHint(now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if hint.Delay != tt.wantDelay || !hint.Until.Equal(tt.wantUntil) {
+				t.Errorf("hint = %+v, want delay %v until %v", hint, tt.wantDelay, tt.wantUntil)
+			}
+		})
+	}
+
+	var nilResp *OrderResp
+	if _, ok := nilResp.SleepHint(now); ok {
+		t.Error("nil 响应不应返回建议")
+	}
+}
+
+func TestOrderPacerSpacing(t *testing.T) {
+	clock := newFakeClock()
+	p := NewOrderPacer(clock)
+	ctx := context.Background()
+
+	if d, err := p.Wait(ctx); err != nil || d != 0 {
+		t.Fatalf("无建议时等待 %v, %v", d, err)
+	}
+
+	start := clock.Now()
+	p.Apply(SleepHint{Delay: 300 * time.Millisecond, Until: start.Add(time.Second)})
+
+	// 建议有效期内各次下单按 300ms 间隔分配
+	var sent []time.Duration
+	for i := 0; i < 3; i++ {
+		if _, err := p.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+		sent = append(sent, clock.Now().Sub(start))
+	}
+	want := []time.Duration{300 * time.Millisecond, 600 * time.Millisecond, 900 * time.Millisecond}
+	for i := range want {
+		if sent[i] != want[i] {
+			t.Errorf("第 %d 次下单在 %v, want %v", i+1, sent[i], want[i])
+		}
+	}
+
+	// 有效期结束后恢复自身节奏
+	clock.Advance(200 * time.Millisecond)
+	if d, _ := p.Wait(ctx); d != 0 {
+		t.Errorf("建议过期后仍等待 %v", d)
+	}
+
+	stats := p.Stats()
+	if stats.Applied != 1 || stats.Waits != 3 || stats.Waited != 900*time.Millisecond {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestOrderPacerWaitCanceled(t *testing.T) {
+	p := NewOrderPacer(nil)
+	p.Apply(SleepHint{Delay: time.Hour, Until: time.Now().Add(time.Hour)})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.Wait(ctx); err == nil {
+		t.Error("ctx 结束时应返回错误")
+	}
+}
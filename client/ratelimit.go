@@ -0,0 +1,257 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// EndpointFamily 共用一个限流桶的一组接口
+type EndpointFamily string
+
+const (
+	FamilyToken   EndpointFamily = "token"
+	FamilyConfirm EndpointFamily = "confirm"
+	FamilyOrder   EndpointFamily = "order"
+	FamilyQuery   EndpointFamily = "query"
+)
+
+// endpointFamily 按路径划分接口族，未知路径归入查询类
+func endpointFamily(path string) EndpointFamily {
+	switch {
+	case path == getTokenPath:
+		return FamilyToken
+	case path == "/order/wap/order/confirm":
+		return FamilyConfirm
+	case strings.HasPrefix(path, "/nj/order/"):
+		return FamilyOrder
+	default:
+		return FamilyQuery
+	}
+}
+
+// BucketConfig 单个接口族的令牌桶配置
+type BucketConfig struct {
+	// Rate 正常情况下每秒允许的请求数
+	Rate float64
+	// Burst 桶容量
+	Burst int
+	// MinRate 限流退避的下限
+	MinRate float64
+}
+
+// LimiterConfig 自适应限流配置
+type LimiterConfig struct {
+	Families map[EndpointFamily]BucketConfig
+	// Backoff 遇到限流时速率乘以该系数
+	Backoff float64
+	// Recover 限流后每隔 RecoverInterval 无限流，速率乘以该系数，直到恢复到 Rate
+	Recover         float64
+	RecoverInterval time.Duration
+}
+
+// DefaultLimiterConfig 默认限流配置，与默认 3 个并发、200~500ms 间隔的下单节奏相当
+var DefaultLimiterConfig = LimiterConfig{
+	Families: map[EndpointFamily]BucketConfig{
+		FamilyToken:   {Rate: 2, Burst: 2, MinRate: 0.2},
+		FamilyConfirm: {Rate: 5, Burst: 3, MinRate: 0.5},
+		FamilyOrder:   {Rate: 10, Burst: 5, MinRate: 1},
+		FamilyQuery:   {Rate: 5, Burst: 5, MinRate: 0.5},
+	},
+	Backoff:         0.5,
+	Recover:         1.25,
+	RecoverInterval: 2 * time.Second,
+}
+
+// RateLimiter 按接口族限流，遇到限流提示时降速并逐步恢复；可在多个 client 间共享
+type RateLimiter struct {
+	cfg     LimiterConfig
+	clock   Clock
+	mu      sync.Mutex
+	buckets map[EndpointFamily]*tokenBucket
+}
+
+func NewRateLimiter(cfg LimiterConfig, clock Clock) *RateLimiter {
+	if clock == nil {
+		clock = SystemClock
+	}
+	if cfg.Families == nil {
+		cfg.Families = DefaultLimiterConfig.Families
+	}
+	if cfg.Backoff <= 0 || cfg.Backoff >= 1 {
+		cfg.Backoff = DefaultLimiterConfig.Backoff
+	}
+	if cfg.Recover <= 1 {
+		cfg.Recover = DefaultLimiterConfig.Recover
+	}
+	if cfg.RecoverInterval <= 0 {
+		cfg.RecoverInterval = DefaultLimiterConfig.RecoverInterval
+	}
+	return &RateLimiter{cfg: cfg, clock: clock, buckets: map[EndpointFamily]*tokenBucket{}}
+}
+
+var (
+	sharedLimiterOnce sync.Once
+	sharedLimiter     *RateLimiter
+)
+
+// SharedRateLimiter 进程内共享的默认限流器，使用本机时间且未指定 WithRateLimiter 的 client 都使用它
+func SharedRateLimiter() *RateLimiter {
+	sharedLimiterOnce.Do(func() {
+		sharedLimiter = NewRateLimiter(DefaultLimiterConfig, SystemClock)
+	})
+	return sharedLimiter
+}
+
+// defaultRateLimiter 未指定 WithRateLimiter 时的限流器：本机时间共享 SharedRateLimiter，
+// 自定义时间来源的 client 单独使用一个按该时间来源计时的限流器
+func defaultRateLimiter(clock Clock) *RateLimiter {
+	if clock == SystemClock {
+		return SharedRateLimiter()
+	}
+	return NewRateLimiter(DefaultLimiterConfig, clock)
+}
+
+func (l *RateLimiter) bucket(family EndpointFamily) *tokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[family]
+	if !ok {
+		cfg, ok := l.cfg.Families[family]
+		if !ok {
+			cfg = l.cfg.Families[FamilyQuery]
+		}
+		b = newTokenBucket(cfg, l.clock.Now())
+		l.buckets[family] = b
+	}
+	return b
+}
+
+// Wait 等待 path 所属接口族的令牌
+func (l *RateLimiter) Wait(ctx context.Context, path string) error {
+	b := l.bucket(endpointFamily(path))
+	for {
+		d := b.reserve(l.clock.Now())
+		if d <= 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-l.clock.After(d):
+		}
+	}
+}
+
+// Observe 根据请求结果调整速率
+func (l *RateLimiter) Observe(path string, err error) {
+	b := l.bucket(endpointFamily(path))
+	now := l.clock.Now()
+	if IsThrottled(err) {
+		b.backoff(now, l.cfg.Backoff)
+		return
+	}
+	b.recover(now, l.cfg.Recover, l.cfg.RecoverInterval)
+}
+
+// Rate 当前速率，便于观察退避情况
+func (l *RateLimiter) Rate(family EndpointFamily) float64 {
+	b := l.bucket(family)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rate
+}
+
+type tokenBucket struct {
+	mu         sync.Mutex
+	cfg        BucketConfig
+	rate       float64
+	tokens     float64
+	last       time.Time
+	lastAdjust time.Time
+	degraded   bool
+}
+
+func newTokenBucket(cfg BucketConfig, now time.Time) *tokenBucket {
+	if cfg.Rate <= 0 {
+		cfg.Rate = 1
+	}
+	if cfg.Burst < 1 {
+		cfg.Burst = 1
+	}
+	if cfg.MinRate <= 0 || cfg.MinRate > cfg.Rate {
+		cfg.MinRate = cfg.Rate / 10
+	}
+	return &tokenBucket{cfg: cfg, rate: cfg.Rate, tokens: float64(cfg.Burst), last: now}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if burst := float64(b.cfg.Burst); b.tokens > burst {
+			b.tokens = burst
+		}
+		b.last = now
+	}
+}
+
+// reserve 有令牌时取走并返回 0，否则返回需要等待的时间
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) backoff(now time.Time, factor float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	b.rate *= factor
+	if b.rate < b.cfg.MinRate {
+		b.rate = b.cfg.MinRate
+	}
+	// 清空令牌，避免积攒的令牌在限流后继续突发
+	b.tokens = 0
+	b.lastAdjust = now
+	b.degraded = true
+}
+
+func (b *tokenBucket) recover(now time.Time, factor float64, interval time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.degraded || b.rate >= b.cfg.Rate || now.Sub(b.lastAdjust) < interval {
+		return
+	}
+	b.refill(now)
+	b.rate *= factor
+	if b.rate >= b.cfg.Rate {
+		b.rate = b.cfg.Rate
+		b.degraded = false
+	}
+	b.lastAdjust = now
+}
+
+// RateLimitInterceptor 请求前等待令牌，请求后根据是否限流调整速率
+func RateLimitInterceptor(l *RateLimiter, logger *zap.Logger) Interceptor {
+	return func(ctx context.Context, call *Call, next Invoker) error {
+		if err := l.Wait(ctx, call.Path); err != nil {
+			call.Err = err
+			return err
+		}
+		err := next(ctx, call)
+		l.Observe(call.Path, err)
+		if IsThrottled(err) {
+			family := endpointFamily(call.Path)
+			logger.Warn("🐢触发限流，降低请求速率", zap.String("path", call.Path), zap.String("family", string(family)), zap.Float64("rate", l.Rate(family)))
+		}
+		return err
+	}
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/staparx/go_showstart/config"
+)
+
+// fakeClock 手动推进的时间来源，After 立即推进时间并返回
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 8, 16, 20, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.Advance(d)
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestTokenBucketBackoffAndRecover(t *testing.T) {
+	clock := newFakeClock()
+	b := newTokenBucket(BucketConfig{Rate: 10, Burst: 5, MinRate: 1}, clock.Now())
+
+	b.backoff(clock.Now(), 0.5)
+	if !approx(b.rate, 5) || b.tokens != 0 || !b.degraded {
+		t.Fatalf("第一次退避后 rate = %v, tokens = %v, degraded = %v", b.rate, b.tokens, b.degraded)
+	}
+	// 令牌已清空，需要按降低后的速率等待
+	if d := b.reserve(clock.Now()); d != 200*time.Millisecond {
+		t.Errorf("退避后等待 %v, want 200ms", d)
+	}
+
+	for i := 0; i < 5; i++ {
+		b.backoff(clock.Now(), 0.5)
+	}
+	if !approx(b.rate, 1) {
+		t.Fatalf("多次退避后 rate = %v, want 下限 1", b.rate)
+	}
+
+	// 距上次调整不足 interval 时不恢复
+	clock.Advance(time.Second)
+	b.recover(clock.Now(), 2, 2*time.Second)
+	if !approx(b.rate, 1) {
+		t.Fatalf("interval 内恢复了速率: %v", b.rate)
+	}
+
+	want := []float64{2, 4, 8, 10}
+	for i, w := range want {
+		clock.Advance(2 * time.Second)
+		b.recover(clock.Now(), 2, 2*time.Second)
+		if !approx(b.rate, w) {
+			t.Fatalf("第 %d 次恢复后 rate = %v, want %v", i+1, b.rate, w)
+		}
+	}
+	if b.degraded {
+		t.Error("恢复到 Rate 后仍标记为降速")
+	}
+	clock.Advance(2 * time.Second)
+	b.recover(clock.Now(), 2, 2*time.Second)
+	if !approx(b.rate, 10) {
+		t.Errorf("恢复后超过 Rate: %v", b.rate)
+	}
+}
+
+func TestRateLimiterObserve(t *testing.T) {
+	clock := newFakeClock()
+	l := NewRateLimiter(DefaultLimiterConfig, clock)
+	path := "/nj/order/order"
+	throttled := &APIError{Path: path, State: "0", Msg: "小手指点得太快啦，休息一下"}
+
+	l.Observe(path, throttled)
+	if got := l.Rate(FamilyOrder); !approx(got, 5) {
+		t.Fatalf("限流后 rate = %v, want 5", got)
+	}
+	// 其他接口族不受影响
+	if got := l.Rate(FamilyQuery); !approx(got, 5) {
+		t.Errorf("query rate = %v, want 5", got)
+	}
+
+	l.Observe(path, nil)
+	if got := l.Rate(FamilyOrder); !approx(got, 5) {
+		t.Errorf("RecoverInterval 内恢复了速率: %v", got)
+	}
+	for i := 0; i < 10; i++ {
+		clock.Advance(DefaultLimiterConfig.RecoverInterval)
+		l.Observe(path, nil)
+	}
+	if got := l.Rate(FamilyOrder); !approx(got, 10) {
+		t.Errorf("恢复后 rate = %v, want 10", got)
+	}
+}
+
+func TestRateLimiterWaitUsesClock(t *testing.T) {
+	clock := newFakeClock()
+	l := NewRateLimiter(LimiterConfig{Families: map[EndpointFamily]BucketConfig{
+		FamilyQuery: {Rate: 4, Burst: 2, MinRate: 1},
+	}}, clock)
+
+	start := clock.Now()
+	for i := 0; i < 6; i++ {
+		if err := l.Wait(context.Background(), "/wap/activity/details"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 突发 2 个，其余 4 个按每秒 4 个
+	if got := clock.Now().Sub(start); got != time.Second {
+		t.Errorf("6 次请求耗时 %v, want 1s", got)
+	}
+}
+
+func TestDefaultRateLimiterFollowsClock(t *testing.T) {
+	cfg := &config.Showstart{Token: "token"}
+
+	if c := newShowStartClient(cfg); c.limiter != SharedRateLimiter() {
+		t.Error("本机时间的 client 应共享 SharedRateLimiter")
+	}
+
+	clock := newFakeClock()
+	c := newShowStartClient(cfg, WithClock(clock))
+	if c.limiter == nil || c.limiter == SharedRateLimiter() || c.limiter.clock != clock {
+		t.Error("自定义时间来源的 client 应使用按该时间来源计时的限流器")
+	}
+
+	if c := newShowStartClient(cfg, WithClock(clock), WithRateLimiter(nil)); c.limiter != nil {
+		t.Error("WithRateLimiter(nil) 应关闭限流")
+	}
+	shared := NewRateLimiter(DefaultLimiterConfig, nil)
+	if c := newShowStartClient(cfg, WithRateLimiter(shared), WithClock(clock)); c.limiter != shared {
+		t.Error("WithRateLimiter 指定的限流器被替换")
+	}
+}
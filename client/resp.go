@@ -1,5 +1,7 @@
 package client
 
+import "github.com/staparx/go_showstart/vars"
+
 type ShowStartCommonResp struct {
 	State   string `json:"state"`
 	Success bool   `json:"success"`
@@ -139,46 +141,46 @@ type ActivityTicket struct {
 }
 
 type TicketInfo struct {
-	TicketID                  string        `json:"ticketId"`
-	TicketType                string        `json:"ticketType"`
-	SellingPrice              string        `json:"sellingPrice"`
-	IsUndetermined            int           `json:"isUndetermined"`
-	CostPrice                 string        `json:"costPrice"`
-	TicketNum                 int           `json:"ticketNum"`
-	ValidateType              int           `json:"validateType"`
-	Time                      string        `json:"time"`
-	Instruction               string        `json:"instruction"`
-	Countdown                 int           `json:"countdown"`
-	RemainTicket              int           `json:"remainTicket"`
-	SaleStatus                int           `json:"saleStatus"`
-	ActivityID                int           `json:"activityId"`
-	GoodType                  int           `json:"goodType"`
-	Telephone                 string        `json:"telephone"`
-	AreaCode                  string        `json:"areaCode"`
-	LimitBuyNum               int           `json:"limitBuyNum"`
-	CanBuyNum                 int           `json:"canBuyNum"`
-	CityName                  string        `json:"cityName"`
-	UnPayOrderNum             int           `json:"unPayOrderNum"`
-	Type                      int           `json:"type"`
-	PickupAddress             string        `json:"pickupAddress"`
-	EntityMailInstruction     string        `json:"entityMailInstruction"`
-	EntityPickupInstruction   string        `json:"entityPickupInstruction"`
-	BuyType                   int           `json:"buyType"`
-	CanAddGoods               int           `json:"canAddGoods"`
-	TicketRecordStatus        int           `json:"ticketRecordStatus"`
-	StartSellNoticeStatus     int           `json:"startSellNoticeStatus"`
-	ShowRuleTip               bool          `json:"showRuleTip"`
-	StartTime                 int64         `json:"startTime"`
-	SessionID                 int           `json:"sessionId"`
-	ShowTime                  string        `json:"showTime"`
-	MemberNum                 int           `json:"memberNum"`
-	Labels                    []interface{} `json:"labels"`
-	EndTime                   string        `json:"endTime"`
-	TransformNum              int           `json:"transformNum"`
-	IsPreAuth                 int           `json:"isPreAuth"`
-	ConfirmPreOrderDetailTips string        `json:"confirmPreOrderDetailTips"`
-	GroupID                   int           `json:"groupId"`
-	IsCommonPerformerList     int           `json:"isCommonPerformerList"`
+	TicketID                  string          `json:"ticketId"`
+	TicketType                vars.TicketType `json:"ticketType"`
+	SellingPrice              string          `json:"sellingPrice"`
+	IsUndetermined            int             `json:"isUndetermined"`
+	CostPrice                 string          `json:"costPrice"`
+	TicketNum                 int             `json:"ticketNum"`
+	ValidateType              int             `json:"validateType"`
+	Time                      string          `json:"time"`
+	Instruction               string          `json:"instruction"`
+	Countdown                 int             `json:"countdown"`
+	RemainTicket              int             `json:"remainTicket"`
+	SaleStatus                vars.SaleStatus `json:"saleStatus"`
+	ActivityID                int             `json:"activityId"`
+	GoodType                  int             `json:"goodType"`
+	Telephone                 string          `json:"telephone"`
+	AreaCode                  string          `json:"areaCode"`
+	LimitBuyNum               int             `json:"limitBuyNum"`
+	CanBuyNum                 int             `json:"canBuyNum"`
+	CityName                  string          `json:"cityName"`
+	UnPayOrderNum             int             `json:"unPayOrderNum"`
+	Type                      int             `json:"type"`
+	PickupAddress             string          `json:"pickupAddress"`
+	EntityMailInstruction     string          `json:"entityMailInstruction"`
+	EntityPickupInstruction   string          `json:"entityPickupInstruction"`
+	BuyType                   vars.BuyType    `json:"buyType"`
+	CanAddGoods               int             `json:"canAddGoods"`
+	TicketRecordStatus        int             `json:"ticketRecordStatus"`
+	StartSellNoticeStatus     int             `json:"startSellNoticeStatus"`
+	ShowRuleTip               bool            `json:"showRuleTip"`
+	StartTime                 int64           `json:"startTime"`
+	SessionID                 int             `json:"sessionId"`
+	ShowTime                  string          `json:"showTime"`
+	MemberNum                 int             `json:"memberNum"`
+	Labels                    []interface{}   `json:"labels"`
+	EndTime                   string          `json:"endTime"`
+	TransformNum              int             `json:"transformNum"`
+	IsPreAuth                 int             `json:"isPreAuth"`
+	ConfirmPreOrderDetailTips string          `json:"confirmPreOrderDetailTips"`
+	GroupID                   int             `json:"groupId"`
+	IsCommonPerformerList     int             `json:"isCommonPerformerList"`
 }
 
 type ConfirmResp struct {
@@ -195,29 +197,29 @@ type ConfirmResp struct {
 			AreaCode      string `json:"areaCode"`
 			Telephone     string `json:"telephone"`
 			TicketPriceVo struct {
-				TicketID                string  `json:"ticketId"`
-				TicketName              string  `json:"ticketName"`
-				Price                   float64 `json:"price"`
-				TicketType              int     `json:"ticketType"`
-				LimitBuyNum             int     `json:"limitBuyNum"`
-				CanBuyNum               int     `json:"canBuyNum"`
-				Instruction             string  `json:"instruction"`
-				EntityMailInstruction   string  `json:"entityMailInstruction"`
-				EntityPickupInstruction string  `json:"entityPickupInstruction"`
-				PickupAddress           string  `json:"pickupAddress"`
-				RemainTicket            int     `json:"remainTicket"`
-				TransformNum            int     `json:"transformNum"`
-				DyPOIType               int     `json:"dyPOIType"`
+				TicketID                string          `json:"ticketId"`
+				TicketName              string          `json:"ticketName"`
+				Price                   float64         `json:"price"`
+				TicketType              vars.TicketType `json:"ticketType"`
+				LimitBuyNum             int             `json:"limitBuyNum"`
+				CanBuyNum               int             `json:"canBuyNum"`
+				Instruction             string          `json:"instruction"`
+				EntityMailInstruction   string          `json:"entityMailInstruction"`
+				EntityPickupInstruction string          `json:"entityPickupInstruction"`
+				PickupAddress           string          `json:"pickupAddress"`
+				RemainTicket            int             `json:"remainTicket"`
+				TransformNum            int             `json:"transformNum"`
+				DyPOIType               int             `json:"dyPOIType"`
 			} `json:"ticketPriceVo"`
-			RealName                    int    `json:"realName"`
-			ValidateType                int    `json:"validateType"`
-			BuyType                     int    `json:"buyType"`
-			MemberNum                   int    `json:"memberNum"`
-			SellAreaType                int    `json:"sellAreaType"`
-			DouyinStatus                int    `json:"douyinStatus"`
-			CommonPerformerDocumentType string `json:"commonPerformerDocumentType"`
-			IsSupportTransform          int    `json:"isSupportTransform"`
-			IsCommonPerformerList       int    `json:"isCommonPerformerList"`
+			RealName                    int          `json:"realName"`
+			ValidateType                int          `json:"validateType"`
+			BuyType                     vars.BuyType `json:"buyType"`
+			MemberNum                   int          `json:"memberNum"`
+			SellAreaType                int          `json:"sellAreaType"`
+			DouyinStatus                int          `json:"douyinStatus"`
+			CommonPerformerDocumentType string       `json:"commonPerformerDocumentType"`
+			IsSupportTransform          int          `json:"isSupportTransform"`
+			IsCommonPerformerList       int          `json:"isCommonPerformerList"`
 		} `json:"orderInfoVo"`
 		ActivityTips []string `json:"activityTips"`
 	} `json:"result"`
@@ -292,12 +294,14 @@ type OrderResp struct {
 	} `json:"result"`
 }
 
+// OrderCoreResp coreOrder 的返回，直接给出订单号，或给出 orderJobKey 继续查询订单结果
 type OrderCoreResp struct {
 	*ShowStartCommonResp
-	Result interface{} `json:"result"`
-	//Result struct {
-	//	OrderJobKey string `json:"orderJobKey"`
-	//} `json:"result"`
+	Result struct {
+		OrderJobKey string `json:"orderJobKey"`
+		OrderSn     string `json:"orderSn"`
+		OrderID     string `json:"orderId"`
+	} `json:"result"`
 }
 
 type GetOrderResultResp struct {
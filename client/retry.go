@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// RetryClass 可重试的错误类别，可按位组合
+type RetryClass uint8
+
+const (
+	// RetryNetwork 网络超时、连接中断等临时错误
+	RetryNetwork RetryClass = 1 << iota
+	// RetryServer 服务端返回 5xx
+	RetryServer
+	// RetryTokenExpired 登录过期，刷新 token 后立即重试
+	RetryTokenExpired
+	// RetryPending 订单仍在处理中
+	RetryPending
+)
+
+// RetryPolicy 重试策略
+type RetryPolicy struct {
+	// MaxAttempts 最大尝试次数（包含第一次请求）
+	MaxAttempts int
+	// BaseDelay 第一次重试前的等待时间
+	BaseDelay time.Duration
+	// MaxDelay 单次等待时间上限
+	MaxDelay time.Duration
+	// Multiplier 每次重试等待时间的放大倍数，小于 1 时按 1 处理
+	Multiplier float64
+	// Jitter 等待时间的随机抖动比例，取值 0~1
+	Jitter float64
+	// Retryable 允许重试的错误类别
+	Retryable RetryClass
+}
+
+var (
+	// DefaultRetryPolicy 普通接口的重试策略
+	DefaultRetryPolicy = RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Multiplier:  2,
+		Jitter:      0.2,
+		Retryable:   RetryNetwork | RetryServer | RetryTokenExpired,
+	}
+
+	// DefaultOrderPolicy 提交订单的重试策略。超时或 5xx 时无法确定订单是否已提交，不自动重试以免重复下单；
+	// 登录过期的请求未被受理，刷新 token 后重试一次
+	DefaultOrderPolicy = RetryPolicy{
+		MaxAttempts: 2,
+		Retryable:   RetryTokenExpired,
+	}
+
+	// DefaultPollPolicy 订单结果轮询的重试策略，pending 时每 200ms 查询一次
+	DefaultPollPolicy = RetryPolicy{
+		MaxAttempts: 50,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    200 * time.Millisecond,
+		Multiplier:  1,
+		Retryable:   RetryNetwork | RetryServer | RetryTokenExpired | RetryPending,
+	}
+)
+
+// RetryError 多次尝试后仍然失败
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v（共尝试 %d 次）", e.Err, e.Attempts)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Do 按策略执行 fn，lastErr 为上一次尝试的错误（第一次为 nil）。
+// 返回实际尝试次数；ctx 取消时立即停止。
+func (p RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context, attempt int, lastErr error) error) (int, error) {
+	return p.do(ctx, SystemClock, fn)
+}
+
+func (p RetryPolicy) do(ctx context.Context, clock Clock, fn func(ctx context.Context, attempt int, lastErr error) error) (int, error) {
+	maxAttempts := p.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return attempt - 1, wrapAttempts(attempt-1, err)
+		}
+
+		lastErr = fn(ctx, attempt, lastErr)
+		if lastErr == nil {
+			return attempt, nil
+		}
+		if ctx.Err() != nil {
+			return attempt, wrapAttempts(attempt, lastErr)
+		}
+
+		class := classifyError(lastErr)
+		if class&p.Retryable == 0 || attempt >= maxAttempts {
+			return attempt, wrapAttempts(attempt, lastErr)
+		}
+
+		// 登录过期只需刷新 token，无需等待
+		if class == RetryTokenExpired {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, wrapAttempts(attempt, lastErr)
+		case <-clock.After(p.delay(attempt)):
+		}
+	}
+}
+
+// delay 第 attempt 次失败后的等待时间
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	d := float64(p.BaseDelay)
+	for i := 1; i < attempt; i++ {
+		d *= multiplier
+		if p.MaxDelay > 0 && d >= float64(p.MaxDelay) {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (rand.Float64()*2 - 1)
+	}
+	if d < 0 {
+		d = 0
+	}
+	return time.Duration(d)
+}
+
+func wrapAttempts(attempts int, err error) error {
+	if attempts <= 1 {
+		return err
+	}
+	return &RetryError{Attempts: attempts, Err: err}
+}
+
+// classifyError 判断错误所属的重试类别，不可重试时返回 0
+func classifyError(err error) RetryClass {
+	if err == nil {
+		return 0
+	}
+	if errors.Is(err, ErrPending) {
+		return RetryPending
+	}
+	if IsTokenExpired(err) {
+		return RetryTokenExpired
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		if httpErr.StatusCode >= 500 {
+			return RetryServer
+		}
+		return 0
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return RetryNetwork
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return RetryNetwork
+	}
+	return 0
+}
@@ -0,0 +1,252 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/staparx/go_showstart/client/showstarttest"
+	"github.com/staparx/go_showstart/config"
+)
+
+// blockingClock After 永不触发，只能由 ctx 结束等待
+type blockingClock struct{}
+
+func (blockingClock) Now() time.Time                       { return time.Now() }
+func (blockingClock) After(time.Duration) <-chan time.Time { return make(chan time.Time) }
+
+var (
+	errNetwork = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	errExpired = &APIError{Path: "/test", State: "token-expire-at", Msg: "登录过期了，请重新登录！"}
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want RetryClass
+	}{
+		{nil, 0},
+		{ErrPending, RetryPending},
+		{fmt.Errorf("wrap: %w", ErrPending), RetryPending},
+		{errExpired, RetryTokenExpired},
+		{&HTTPError{StatusCode: 502}, RetryServer},
+		{&HTTPError{StatusCode: 429}, 0},
+		{&HTTPError{StatusCode: 400}, 0},
+		{io.ErrUnexpectedEOF, RetryNetwork},
+		{errNetwork, RetryNetwork},
+		{&APIError{State: "0", Msg: "库存不足"}, 0},
+		{errors.New("other"), 0},
+	}
+	for _, tt := range tests {
+		if got := classifyError(tt.err); got != tt.want {
+			t.Errorf("classifyError(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryAttemptsAndBackoff(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	p := RetryPolicy{MaxAttempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond, Multiplier: 2, Retryable: RetryNetwork}
+
+	var calls int
+	var seen []error
+	attempts, err := p.do(context.Background(), clock, func(ctx context.Context, attempt int, lastErr error) error {
+		calls++
+		if attempt != calls {
+			t.Errorf("attempt = %d, want %d", attempt, calls)
+		}
+		seen = append(seen, lastErr)
+		return errNetwork
+	})
+	if attempts != 4 || calls != 4 {
+		t.Fatalf("attempts = %d, calls = %d, want 4", attempts, calls)
+	}
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 4 || !errors.Is(err, errNetwork) {
+		t.Fatalf("err = %v, want 4 次尝试的 RetryError", err)
+	}
+	if seen[0] != nil || seen[1] != errNetwork {
+		t.Errorf("lastErr = %v", seen)
+	}
+	// 100ms + 200ms + 300ms（封顶），最后一次失败后不再等待
+	if d := clock.Now().Sub(start); d != 600*time.Millisecond {
+		t.Errorf("等待 %v, want 600ms", d)
+	}
+}
+
+func TestRetrySucceedsAfterRetry(t *testing.T) {
+	clock := newFakeClock()
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 50 * time.Millisecond, Retryable: RetryServer}
+
+	attempts, err := p.do(context.Background(), clock, func(ctx context.Context, attempt int, lastErr error) error {
+		if attempt < 3 {
+			return &HTTPError{StatusCode: 503}
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Fatalf("attempts = %d, err = %v", attempts, err)
+	}
+}
+
+func TestRetryNonRetryableReturnsAfterOneAttempt(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, Retryable: RetryNetwork | RetryServer | RetryTokenExpired}
+	for _, want := range []error{
+		&HTTPError{StatusCode: 400},
+		&APIError{State: "0", Msg: "库存不足"},
+		ErrPending,
+	} {
+		clock := newFakeClock()
+		start := clock.Now()
+		var calls int
+		attempts, err := p.do(context.Background(), clock, func(ctx context.Context, attempt int, lastErr error) error {
+			calls++
+			return want
+		})
+		if attempts != 1 || calls != 1 || err != want {
+			t.Errorf("%v: attempts = %d, calls = %d, err = %v", want, attempts, calls, err)
+		}
+		if !clock.Now().Equal(start) {
+			t.Errorf("%v: 不应等待", want)
+		}
+	}
+}
+
+func TestRetryTokenExpiredDoesNotWait(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	p := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, Retryable: RetryTokenExpired}
+
+	attempts, err := p.do(context.Background(), clock, func(ctx context.Context, attempt int, lastErr error) error {
+		if attempt == 1 {
+			return errExpired
+		}
+		if !IsTokenExpired(lastErr) {
+			t.Errorf("lastErr = %v, want 登录过期", lastErr)
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Fatalf("attempts = %d, err = %v", attempts, err)
+	}
+	if !clock.Now().Equal(start) {
+		t.Errorf("登录过期重试等待了 %v", clock.Now().Sub(start))
+	}
+}
+
+func TestRetryCancelStopsBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, Retryable: RetryNetwork}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	var (
+		attempts int
+		err      error
+	)
+	go func() {
+		defer close(done)
+		attempts, err = p.do(ctx, blockingClock{}, func(ctx context.Context, attempt int, lastErr error) error {
+			return errNetwork
+		})
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ctx 取消后仍在等待重试")
+	}
+	// 返回最后一次请求的错误，而不是 ctx 的错误
+	if attempts != 1 || err != errNetwork {
+		t.Errorf("attempts = %d, err = %v", attempts, err)
+	}
+}
+
+func TestRetryCanceledBeforeFirstAttempt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var calls int
+	attempts, err := DefaultRetryPolicy.do(ctx, newFakeClock(), func(ctx context.Context, attempt int, lastErr error) error {
+		calls++
+		return nil
+	})
+	if attempts != 0 || calls != 0 || !errors.Is(err, context.Canceled) {
+		t.Errorf("attempts = %d, calls = %d, err = %v", attempts, calls, err)
+	}
+}
+
+func TestRetryDelayJitter(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, Multiplier: 2, MaxDelay: time.Second, Jitter: 0.2}
+	for i := 0; i < 100; i++ {
+		if d := p.delay(2); d < 160*time.Millisecond || d > 240*time.Millisecond {
+			t.Fatalf("delay(2) = %v, want 200ms ± 20%%", d)
+		}
+	}
+}
+
+func testShowstartConfig(s *showstarttest.Server) *config.Showstart {
+	return &config.Showstart{
+		Sign:      "sig",
+		Token:     "abcdefghijklmnopqrstuvwxyz0123456789",
+		Cterminal: "wap",
+		Cversion:  "997",
+		Cusid:     "1",
+		Cusname:   "nil",
+		BaseURL:   s.BaseURL,
+	}
+}
+
+// newTestClient 创建指向模拟服务、关闭限流并使用 fakeClock 的 client，并获取 token
+func newTestClient(t *testing.T, s *showstarttest.Server, opts ...Option) *ShowStartClient {
+	t.Helper()
+	opts = append([]Option{WithRateLimiter(nil), WithClock(newFakeClock())}, opts...)
+	c := newShowStartClient(testShowstartConfig(s), opts...)
+	if err := c.GetToken(context.Background()); err != nil {
+		t.Fatalf("GetToken: %v", err)
+	}
+	return c
+}
+
+func TestOrderDoesNotRetryUncertainFailures(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	c := newTestClient(t, s)
+	req := &OrderReq{
+		OrderDetails:      []*OrderDetail{{GoodsType: 1, SkuType: 1, Num: "1", GoodsID: 123456, SkuID: "ticket-388", Price: 388}},
+		CommonPerfomerIds: []int{5773864},
+		SessionID:         3249212,
+	}
+
+	// 5xx 时订单可能已提交，不能重复下单
+	s.Enqueue("/nj/order/order", showstarttest.Reply{HTTPStatus: 502})
+	if _, err := c.Order(context.Background(), req); err == nil {
+		t.Fatal("Order 应返回 5xx 错误")
+	}
+	if n := s.Count("/nj/order/order"); n != 1 {
+		t.Fatalf("5xx 后下单请求 %d 次, want 1", n)
+	}
+
+	// 普通接口的 5xx 仍按默认策略重试
+	s.Enqueue("/wap/activity/details", showstarttest.Reply{HTTPStatus: 502})
+	if _, err := c.ActivityDetail(context.Background(), s.Fixture().ActivityID); err != nil {
+		t.Fatalf("ActivityDetail: %v", err)
+	}
+	if n := s.Count("/wap/activity/details"); n != 2 {
+		t.Errorf("details 请求 %d 次, want 2", n)
+	}
+
+	// 登录过期的下单请求未被受理，刷新 token 后重试
+	s.ExpireToken()
+	if _, err := c.Order(context.Background(), req); err != nil {
+		t.Fatalf("登录过期后下单: %v", err)
+	}
+	if n := s.Count("/nj/order/order"); n != 3 {
+		t.Errorf("下单请求 %d 次, want 3", n)
+	}
+}
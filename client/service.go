@@ -2,12 +2,9 @@ package client
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"time"
 
 	jsoniter "github.com/json-iterator/go"
-	"github.com/staparx/go_showstart/log"
 )
 
 type ShowStartIface interface {
@@ -32,35 +29,22 @@ type ShowStartIface interface {
 	CoreOrder(ctx context.Context, coreOrderKey string) (*OrderCoreResp, error)
 	// GetOrderResult 获取订单结果
 	GetOrderResult(ctx context.Context, orderJobKey string) (*GetOrderResultResp, error)
+	// SyncClock 通过响应的 Date 头估计服务端时钟偏差
+	SyncClock(ctx context.Context, samples int) (ClockOffset, error)
+	// Warmup 开售前建立并保持 conns 个空闲连接
+	Warmup(ctx context.Context, conns int) (int, error)
 }
 
-// GetToken 获取token
+// GetToken 获取token，并发调用时只会发出一次请求
 func (c *ShowStartClient) GetToken(ctx context.Context) error {
-	path := "/waf/gettoken"
-	data := fmt.Sprintf(`{"st_flpv":"%s","sign":"%s","trackPath":""}`, c.StFlpv, c.Sign)
-
-	result, err := c.Post(ctx, path, data)
-	if err != nil {
-		return err
-	}
-
-	// 添加 GetToken 的返回值到Debug日志中
-	log.Logger.Debug("GetToken:" + string(result))
-
-	var resp *GetTokenResp
-	err = jsoniter.Unmarshal(result, &resp)
-	if err != nil {
-		return err
-	}
-
-	if !resp.Success {
-		return errors.New(resp.Msg)
-	}
+	return c.creds.Refresh(ctx)
+}
 
-	c.Cusat = resp.Result.AccessToken.AccessToken
-	c.Cusit = resp.Result.IDToken.IDToken
+// fetchToken 请求新的 token
+func (c *ShowStartClient) fetchToken(ctx context.Context) (*GetTokenResp, error) {
+	data := fmt.Sprintf(`{"st_flpv":"%s","sign":"%s","trackPath":""}`, c.StFlpv, c.Sign)
 
-	return nil
+	return doRequest[GetTokenResp](ctx, c, getTokenPath, data)
 }
 
 // ActivityDetail 获取活动详情
@@ -69,29 +53,7 @@ func (c *ShowStartClient) ActivityDetail(ctx context.Context, activityId int) (*
 	data := fmt.Sprintf(`{"activityId":"%d","coupon":"","shareId":"","st_flpv":"%s","sign":"%s","trackPath":""}`,
 		activityId, c.StFlpv, c.Sign)
 
-	result, err := c.Post(ctx, path, data)
-	if err != nil {
-		return nil, err
-	}
-
-	var resp *ActivityDetailResp
-	err = jsoniter.Unmarshal(result, &resp)
-	if err != nil {
-		return nil, err
-	}
-	if resp.State == "token-expire-at" || resp.Msg == "登录过期了，请重新登录！" {
-		err = c.GetToken(ctx)
-		if err != nil {
-			return nil, err
-		}
-		return c.ActivityDetail(ctx, activityId)
-	}
-
-	if resp.State != "1" {
-		return nil, errors.New(resp.Msg)
-	}
-
-	return resp, nil
+	return doRequest[ActivityDetailResp](ctx, c, path, data)
 }
 
 // ActivityTicketList 获取票务场次信息
@@ -100,29 +62,7 @@ func (c *ShowStartClient) ActivityTicketList(ctx context.Context, activityId int
 	data := fmt.Sprintf(`{"activityId":"%d","coupon":"","st_flpv":"%s","sign":"%s","trackPath":""}`,
 		activityId, c.StFlpv, c.Sign)
 
-	result, err := c.Post(ctx, path, data)
-	if err != nil {
-		return nil, err
-	}
-
-	var resp *ActivityTicketListResp
-	err = jsoniter.Unmarshal(result, &resp)
-	if err != nil {
-		return nil, err
-	}
-	if resp.State == "token-expire-at" || resp.Msg == "登录过期了，请重新登录！" {
-		err = c.GetToken(ctx)
-		if err != nil {
-			return nil, err
-		}
-		return c.ActivityTicketList(ctx, activityId)
-	}
-
-	if resp.State != "1" {
-		return nil, errors.New(resp.Msg)
-	}
-
-	return resp, nil
+	return doRequest[ActivityTicketListResp](ctx, c, path, data)
 }
 
 // Confirm 确认购买
@@ -131,28 +71,7 @@ func (c *ShowStartClient) Confirm(ctx context.Context, activityId int, ticketId,
 	body := fmt.Sprintf(`{"ticketId":"%s","sequence":"%d","ticketNum":"%s","st_flpv":"%s","sign":"%s","trackPath":""}`,
 		ticketId, activityId, ticketNum, c.StFlpv, c.Sign)
 
-	result, err := c.Post(ctx, path, body)
-	if err != nil {
-		return nil, err
-	}
-
-	// 将 Confirm 的返回值打印到Debug日志中
-	log.Logger.Debug("Confirm:" + string(result))
-
-	var resp *ConfirmResp
-	err = jsoniter.Unmarshal(result, &resp)
-	if err != nil {
-		return nil, err
-	}
-	if resp.State == "token-expire-at" || resp.Msg == "登录过期了，请重新登录！" {
-		err = c.GetToken(ctx)
-		if err != nil {
-			return nil, err
-		}
-		return c.Confirm(ctx, activityId, ticketId, ticketNum)
-	}
-
-	return resp, nil
+	return doRequest[ConfirmResp](ctx, c, path, body)
 }
 
 // AdressList 地址列表
@@ -160,25 +79,7 @@ func (c *ShowStartClient) AdressList(ctx context.Context) (*AdressListResp, erro
 	path := "/wap/address/list"
 	data := fmt.Sprintf(`{"st_flpv":"%s","sign":"%s","trackPath":""}`, c.StFlpv, c.Sign)
 
-	result, err := c.Post(ctx, path, data)
-	if err != nil {
-		return nil, err
-	}
-
-	var resp *AdressListResp
-	err = jsoniter.Unmarshal(result, &resp)
-	if err != nil {
-		return nil, err
-	}
-	if resp.State == "token-expire-at" || resp.Msg == "登录过期了，请重新登录！" {
-		err = c.GetToken(ctx)
-		if err != nil {
-			return nil, err
-		}
-		return c.AdressList(ctx)
-	}
-
-	return resp, nil
+	return doRequest[AdressListResp](ctx, c, path, data)
 }
 
 // CpList 观演人列表
@@ -187,25 +88,7 @@ func (c *ShowStartClient) CpList(ctx context.Context, ticketId string) (*CpListR
 	data := fmt.Sprintf(`{"ticketPriceId":"%s","audienceWhitelistPolicy":0,"st_flpv":"%s","sign":"%s","trackPath":""}`,
 		ticketId, c.StFlpv, c.Sign)
 
-	result, err := c.Post(ctx, path, data)
-	if err != nil {
-		return nil, err
-	}
-
-	var resp *CpListResp
-	err = jsoniter.Unmarshal(result, &resp)
-	if err != nil {
-		return nil, err
-	}
-	if resp.State == "token-expire-at" || resp.Msg == "登录过期了，请重新登录！" {
-		err = c.GetToken(ctx)
-		if err != nil {
-			return nil, err
-		}
-		return c.CpList(ctx, ticketId)
-	}
-
-	return resp, nil
+	return doRequest[CpListResp](ctx, c, path, data)
 }
 
 func (c *ShowStartClient) OrderList(ctx context.Context, req *OrderListReq) (*OrderListResp, error) {
@@ -216,152 +99,39 @@ func (c *ShowStartClient) OrderList(ctx context.Context, req *OrderListReq) (*Or
 	}
 	//e = "{\"orderDetails\":[{\"goodsType\":1,\"skuType\":1,\"num\":\"1\",\"goodsId\":233792,\"skuId\":\"5f62b72525b041791b237911d064609a\",\"price\":188,\"goodsPhoto\":\"https://s2.showstart.com/img/2024/0703/15/30/00f9cd985a664df2b38797b2dcd78558_1200_1600_3167694.0x0.png\",\"dyPOIType\":2,\"goodsName\":\"SFNT「City Walk 都市漫游」巡回演唱会 -深圳站\"}],\"commonPerfomerIds\":[5773864],\"areaCode\":\"86_CN\",\"telephone\":\"15813830747\",\"addressId\":\"\",\"teamId\":\"\",\"couponId\":\"\",\"checkCode\":\"\",\"source\":0,\"discount\":0,\"sessionId\":3249212,\"freight\":0,\"amountPayable\":\"188.00\",\"totalAmount\":\"188.00\",\"partner\":\"\",\"orderSource\":1,\"videoId\":\"\",\"payVideotype\":\"\",\"st_flpv\":\"kgUBxCv3t7EN1wWr6Yeo\",\"sign\":\"f65f2dbcd7387b2376bcefe13754856b\",\"trackPath\":\"\"}", n = "341sW411imd3cgg7"
 
-	result, err := c.Post(ctx, path, data)
-	if err != nil {
-		return nil, err
-	}
-
-	var resp *OrderListResp
-	err = jsoniter.Unmarshal(result, &resp)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.State == "token-expire-at" || resp.Msg == "登录过期了，请重新登录！" {
-		err = c.GetToken(ctx)
-		if err != nil {
-			return nil, err
-		}
-		return c.OrderList(ctx, req)
-	}
-
-	return resp, nil
+	return doRequest[OrderListResp](ctx, c, path, data)
 }
 
+// Order 下单；req 可被多个下单协程共用，不会被修改
 func (c *ShowStartClient) Order(ctx context.Context, req *OrderReq) (*OrderResp, error) {
-	req.StFlpv = c.StFlpv
-	req.Sign = c.Sign
+	r := *req
+	r.StFlpv = c.StFlpv
+	r.Sign = c.Sign
 
 	path := "/nj/order/order"
-	data, err := jsoniter.MarshalToString(req)
+	data, err := jsoniter.MarshalToString(&r)
 	if err != nil {
 		return nil, err
 	}
-	result, err := c.Post(ctx, path, data)
-	if err != nil {
-		return nil, err
-	}
-
-	// 将 Order 的返回值打印到Debug日志中
-	log.Logger.Debug("Order:" + string(result))
-
-	var resp *OrderResp
-	err = jsoniter.Unmarshal(result, &resp)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.State == "token-expire-at" || resp.Msg == "登录过期了，请重新登录！" {
-		err = c.GetToken(ctx)
-		if err != nil {
-			return nil, err
-		}
-		return c.Order(ctx, req)
-	}
 
-	if resp.Success || resp.State == "1" {
-		return resp, nil
-	}
-
-	return nil, errors.New(resp.Msg)
+	// 结果不确定的失败不重试，见 DefaultOrderPolicy
+	return doRequestWithPolicy[OrderResp](ctx, c, c.order, path, data)
 }
 
-// 未使用
+// CoreOrder 下单返回 coreOrderKey 时确认订单，pending 时按轮询策略继续查询
 func (c *ShowStartClient) CoreOrder(ctx context.Context, coreOrderKey string) (*OrderCoreResp, error) {
 	path := "/nj/order/coreOrder"
-
 	body := fmt.Sprintf(`{"coreOrderKey":"%s","st_flpv":"%s","sign":"%s","trackPath":""}`, coreOrderKey, c.StFlpv, c.Sign)
 
-	result, err := c.Post(ctx, path, body)
-	if err != nil {
-		return nil, err
-	}
-
-	var resp *OrderCoreResp
-	err = jsoniter.Unmarshal(result, &resp)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.State == "token-expire-at" || resp.Msg == "登录过期了，请重新登录！" {
-		err = c.GetToken(ctx)
-		if err != nil {
-			return nil, err
-		}
-		return c.CoreOrder(ctx, coreOrderKey)
-	}
-
-	if resultStr, ok := resp.Result.(string); ok {
-		if resultStr == "pending" {
-			time.Sleep(200 * time.Millisecond)
-			return c.CoreOrder(ctx, coreOrderKey)
-		}
-	}
-
-	return resp, nil
+	return doRequestWithPolicy[OrderCoreResp](ctx, c, c.poll, path, body)
 }
 
 func (c *ShowStartClient) GetOrderResult(ctx context.Context, orderJobKey string) (*GetOrderResultResp, error) {
 	path := "/nj/order/getOrderResult"
-
 	body := fmt.Sprintf(`{"orderJobKey":"%s","st_flpv":"%s","sign":"%s","trackPath":""}`, orderJobKey, c.StFlpv, c.Sign)
 
-	result, err := c.Post(ctx, path, body)
-	if err != nil {
-		return nil, err
-	}
-
-	// 将 GetOrderResult 的返回值打印到Debug日志中
-	log.Logger.Debug("GetOrderResult: " + string(result))
-
-	// 修复返回值为 pending 时的解析问题
-	type CommonResp struct {
-		Success bool        `json:"success"`
-		Result  interface{} `json:"result"`
-	}
-
-	var commonResp CommonResp
-	err = jsoniter.Unmarshal(result, &commonResp)
-	if err != nil {
-		return nil, err
-	}
-
-	if commonResp.Success && commonResp.Result == "pending" {
-		// 如果 success 为 true 且 result 为 "pending"，则等待 200ms 后再次查询
-		time.Sleep(200 * time.Millisecond)
-		return c.GetOrderResult(ctx, orderJobKey)
-	}
-
-	// 否则，继续解析为 GetOrderResultResp
-	var resp GetOrderResultResp
-	err = jsoniter.Unmarshal(result, &resp)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.State == "token-expire-at" || resp.Msg == "登录过期了，请重新登录！" {
-		err = c.GetToken(ctx)
-		if err != nil {
-			return nil, err
-		}
-		return c.GetOrderResult(ctx, orderJobKey)
-	}
-
-	if resp.Success || resp.State == "1" {
-		return &resp, nil
-	}
-
-	return nil, errors.New(resp.Msg)
+	// 订单仍在处理中时按轮询策略继续查询
+	return doRequestWithPolicy[GetOrderResultResp](ctx, c, c.poll, path, body)
 }
 
 func (c *ShowStartClient) ActivitySearchList(ctx context.Context, cityCode, keyword string) (*ActivitySearchListResp, error) {
@@ -369,26 +139,5 @@ func (c *ShowStartClient) ActivitySearchList(ctx context.Context, cityCode, keyw
 	body := fmt.Sprintf(`{"pageNo":1,"cityCode":"%s","keyword":"%s","style":"","activityIds":"","couponCode":"","performerId":"","hosterId":"","siteId":"","tag":"","tourId":"","themeId":"","st_flpv":"%s","sign":"%s","trackPath":""}`,
 		cityCode, keyword, c.StFlpv, c.Sign)
 
-	result, err := c.Post(ctx, path, body)
-	if err != nil {
-		return nil, err
-	}
-
-	var resp ActivitySearchListResp
-	if err := jsoniter.Unmarshal(result, &resp); err != nil {
-		return nil, err
-	}
-
-	if resp.State == "token-expire-at" || resp.Msg == "登录过期了，请重新登录！" {
-		if err := c.GetToken(ctx); err != nil {
-			return nil, err
-		}
-		return c.ActivitySearchList(ctx, cityCode, keyword)
-	}
-
-	if resp.State != "1" {
-		return nil, errors.New(resp.Msg)
-	}
-
-	return &resp, nil
+	return doRequest[ActivitySearchListResp](ctx, c, path, body)
 }
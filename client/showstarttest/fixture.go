@@ -0,0 +1,92 @@
+package showstarttest
+
+// Fixture 模拟服务返回的活动、票档、观演人与地址数据
+type Fixture struct {
+	ActivityID   int
+	ActivityName string
+	// Timed 活动搜索结果是否带有“支持定时购票”标签
+	Timed bool
+	// BuyType confirm 返回的 buyType，2 表示需要选择观演人
+	BuyType int
+	// DocumentType 场次的 commonPerformerDocumentType
+	DocumentType string
+	Sessions     []*Session
+	Audiences    []*Audience
+	Addresses    []*Address
+	Telephone    string
+}
+
+// Session 场次
+type Session struct {
+	ID     int
+	Name   string
+	Prices []*Ticket
+}
+
+// Ticket 票档
+type Ticket struct {
+	ID    string
+	Price string
+	// TicketType confirm 返回的 ticketType，2 表示需要填写地址
+	TicketType  int
+	StartTime   int64
+	SaleStatus  int
+	LimitBuyNum int
+	CanBuyNum   int
+	Remain      int
+}
+
+// Audience 观演人
+type Audience struct {
+	ID           int
+	Name         string
+	DocumentType int
+	Document     string
+}
+
+// Address 收货地址
+type Address struct {
+	ID        int
+	Address   string
+	IsDefault bool
+}
+
+// DefaultFixture 与 config.example.yaml 中的活动、场次、票价、观演人一致
+func DefaultFixture() *Fixture {
+	return &Fixture{
+		ActivityID:   123456,
+		ActivityName: "go_showstart 测试活动",
+		Timed:        true,
+		BuyType:      2,
+		DocumentType: "1",
+		Sessions: []*Session{
+			{
+				ID:   3249212,
+				Name: "2024-08-16 周五 20:00",
+				Prices: []*Ticket{
+					{ID: "ticket-388", Price: "388", TicketType: 1, SaleStatus: 1, LimitBuyNum: 4, CanBuyNum: 4, Remain: 100},
+					{ID: "ticket-588", Price: "588", TicketType: 1, SaleStatus: 1, LimitBuyNum: 4, CanBuyNum: 4, Remain: 100},
+				},
+			},
+		},
+		Audiences: []*Audience{
+			{ID: 5773864, Name: "观演人1", DocumentType: 1, Document: "110101199001011234"},
+			{ID: 5773865, Name: "观演人2", DocumentType: 1, Document: "110101199202022345"},
+		},
+		Addresses: []*Address{
+			{ID: 1, Address: "上海市测试路 1 号", IsDefault: true},
+		},
+		Telephone: "13800000000",
+	}
+}
+
+func (f *Fixture) findTicket(ticketID string) (*Session, *Ticket) {
+	for _, session := range f.Sessions {
+		for _, ticket := range session.Prices {
+			if ticket.ID == ticketID {
+				return session, ticket
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,597 @@
+// Package showstarttest 提供基于 httptest 的秀动 /v3 接口模拟服务，用于在不访问线上环境的情况下端到端地验证抢票与监控流程。
+//
+// 模拟服务与真实客户端使用同一套 util.GenerateKey/AES 加密与 crpsign 签名规则，
+// 并支持预设 pending、登录过期、限流、售罄等行为。
+package showstarttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/staparx/go_showstart/util"
+	"github.com/staparx/go_showstart/vars"
+)
+
+const (
+	// PathPrefix 接口路径前缀，客户端的 BaseURL 应为 Server.URL + PathPrefix
+	PathPrefix = "/v3"
+
+	// ThrottleMsg 秀动的限流提示
+	ThrottleMsg = "小手指点得太快啦，休息一下"
+	// SoldOutMsg 售罄提示
+	SoldOutMsg = "票已售罄"
+	// LimitMsg 超过限购数量提示
+	LimitMsg = "超过限购数量"
+	// SignErrorMsg crpsign 校验失败提示
+	SignErrorMsg = "crpsign 校验失败"
+)
+
+// Reply 预设的一次返回，优先于默认处理逻辑
+type Reply struct {
+	// HTTPStatus 为 0 时返回 200
+	HTTPStatus int
+	State      string
+	Success    bool
+	Msg        string
+	Result     interface{}
+}
+
+// Request 模拟服务收到的一次请求，Body 为解密后的明文
+type Request struct {
+	Path   string
+	Header http.Header
+	Body   string
+	// Time 收到请求的时间，可用于检查请求节奏
+	Time time.Time
+}
+
+// Server 秀动接口模拟服务
+type Server struct {
+	*httptest.Server
+	// BaseURL 供 client.WithBaseURL 使用
+	BaseURL string
+
+	mu             sync.Mutex
+	fixture        *Fixture
+	tokenGen       int
+	pending        int
+	throttle       map[string]int
+	soldOut        bool
+	limitReached   map[string]bool
+	replies        map[string][]Reply
+	requests       []*Request
+	counts         map[string]int
+	signFailures   int
+	jobSeq         int
+	orders         []string
+	pendingByJobID map[string]int
+	sleep          float64
+	sleepExpire    float64
+	sleepOnly      bool
+	coreOrder      bool
+	clockSkew      time.Duration
+	latency        map[string]time.Duration
+}
+
+// NewServer 启动模拟服务，fixture 为 nil 时使用 DefaultFixture
+func NewServer(fixture *Fixture) *Server {
+	s := newServer(fixture)
+	s.Server = httptest.NewServer(s)
+	s.BaseURL = s.Server.URL + PathPrefix
+	return s
+}
+
+// NewUnstartedServer 创建未启动的模拟服务，可自行设置监听地址后调用 Start
+func NewUnstartedServer(fixture *Fixture) *Server {
+	s := newServer(fixture)
+	s.Server = httptest.NewUnstartedServer(s)
+	return s
+}
+
+// Start 启动 NewUnstartedServer 创建的服务
+func (s *Server) Start() {
+	s.Server.Start()
+	s.BaseURL = s.Server.URL + PathPrefix
+}
+
+func newServer(fixture *Fixture) *Server {
+	if fixture == nil {
+		fixture = DefaultFixture()
+	}
+	return &Server{
+		fixture:        fixture,
+		tokenGen:       1,
+		throttle:       map[string]int{},
+		replies:        map[string][]Reply{},
+		counts:         map[string]int{},
+		pendingByJobID: map[string]int{},
+		limitReached:   map[string]bool{},
+		latency:        map[string]time.Duration{},
+	}
+}
+
+// Fixture 返回模拟数据，修改前请勿与请求并发
+func (s *Server) Fixture() *Fixture {
+	return s.fixture
+}
+
+// SetPending 之后每个 orderJobKey / coreOrderKey 查询结果前先返回 n 次 pending
+func (s *Server) SetPending(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = n
+}
+
+// ExpireToken 使已下发的 token 失效，之后携带旧 token 的请求返回 token-expire-at
+func (s *Server) ExpireToken() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenGen++
+}
+
+// SetSleepHint 下单返回中携带 sleep 与 sleepExipre；onlyHint 为 true 时不返回 orderJobKey，
+// 模拟服务端只下发节奏建议的情况。sleep 为 0 时取消
+func (s *Server) SetSleepHint(sleep, sleepExpire float64, onlyHint bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sleep = sleep
+	s.sleepExpire = sleepExpire
+	s.sleepOnly = onlyHint
+}
+
+// SetCoreOrder 下单返回 coreOrderKey 而不是 orderJobKey，需通过 /nj/order/coreOrder 确认
+func (s *Server) SetCoreOrder(enable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.coreOrder = enable
+}
+
+// SetThrottle 接下来 n 次请求 path 时返回限流提示
+func (s *Server) SetThrottle(path string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.throttle[path] = n
+}
+
+// SetSoldOut 设置下单时是否返回售罄
+func (s *Server) SetSoldOut(soldOut bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.soldOut = soldOut
+}
+
+// SetRemain 设置票档余票，每次下单返回 orderJobKey / coreOrderKey 时减 1，为 0 时下单该票档返回售罄
+func (s *Server) SetRemain(ticketID string, remain int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ticket := s.fixture.findTicket(ticketID); ticket != nil {
+		ticket.Remain = remain
+	}
+}
+
+// Remain 票档当前余票，票档不存在时返回 -1
+func (s *Server) Remain(ticketID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ticket := s.fixture.findTicket(ticketID); ticket != nil {
+		return ticket.Remain
+	}
+	return -1
+}
+
+// SetLimitReached 设置下单该票档时是否返回超过限购数量
+func (s *Server) SetLimitReached(ticketID string, reached bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limitReached[ticketID] = reached
+}
+
+// SetClockSkew 响应 Date 头使用本机时间加上 skew，模拟服务端时钟与本地不一致
+func (s *Server) SetClockSkew(skew time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clockSkew = skew
+}
+
+// SetLatency path 的请求在处理前等待 d，模拟网络与服务端耗时，使并发请求在服务端处理前都已发出
+func (s *Server) SetLatency(path string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency[path] = d
+}
+
+// Enqueue 为 path 追加预设返回，按顺序消费
+func (s *Server) Enqueue(path string, replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies[path] = append(s.replies[path], replies...)
+}
+
+// Requests 已收到的请求
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Request(nil), s.requests...)
+}
+
+// Count 已收到的 path 请求次数
+func (s *Server) Count(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts[path]
+}
+
+// SignFailures crpsign 校验失败次数
+func (s *Server) SignFailures() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.signFailures
+}
+
+// Orders 已成功的订单号
+func (s *Server) Orders() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.orders...)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	skew := s.clockSkew
+	s.mu.Unlock()
+	if skew != 0 {
+		w.Header().Set("Date", time.Now().Add(skew).UTC().Format(http.TimeFormat))
+	}
+	// 对时请求只需要 Date 头
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, PathPrefix)
+
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	body, err := decodeBody(path, r.Header, string(raw))
+	if err != nil {
+		s.write(w, Reply{State: "-1", Msg: "请求解密失败：" + err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	latency := s.latency[path]
+	s.mu.Unlock()
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, &Request{Path: path, Header: r.Header.Clone(), Body: body, Time: time.Now()})
+	s.counts[path]++
+
+	if !verifySign(path, r.Header, string(raw)) {
+		s.signFailures++
+		s.mu.Unlock()
+		s.write(w, Reply{State: "-1", Msg: SignErrorMsg})
+		return
+	}
+
+	if queue := s.replies[path]; len(queue) > 0 {
+		reply := queue[0]
+		s.replies[path] = queue[1:]
+		s.mu.Unlock()
+		s.write(w, reply)
+		return
+	}
+
+	if path != "/waf/gettoken" && s.tokenExpired(r.Header.Get("cusat")) {
+		s.mu.Unlock()
+		s.write(w, Reply{State: "token-expire-at", Msg: "登录过期了，请重新登录！"})
+		return
+	}
+
+	if s.throttle[path] > 0 {
+		s.throttle[path]--
+		s.mu.Unlock()
+		s.write(w, Reply{State: "0", Msg: ThrottleMsg})
+		return
+	}
+
+	reply := s.handle(path, body)
+	s.mu.Unlock()
+	s.write(w, reply)
+}
+
+// handle 默认处理逻辑，调用方需持有 s.mu
+func (s *Server) handle(path, body string) Reply {
+	var req map[string]interface{}
+	_ = json.Unmarshal([]byte(body), &req)
+
+	switch path {
+	case "/waf/gettoken":
+		return ok(map[string]interface{}{
+			"accessToken": map[string]interface{}{"access_token": s.accessToken(), "expire": 3600},
+			"idToken":     map[string]interface{}{"id_token": fmt.Sprintf("it-%d", s.tokenGen), "expire": 3600},
+		})
+	case "/wap/activity/list":
+		return ok(map[string]interface{}{"activityInfo": s.activityList(stringField(req, "keyword"))})
+	case "/wap/activity/details":
+		return ok(map[string]interface{}{
+			"activityId":   s.fixture.ActivityID,
+			"activityName": s.fixture.ActivityName,
+			"title":        s.fixture.ActivityName,
+			"sellTerminal": 0,
+			"isPreAuth":    0,
+			"douyinStatus": 0,
+		})
+	case "/wap/activity/V2/ticket/list":
+		return ok(s.ticketList())
+	case "/order/wap/order/confirm":
+		return s.confirm(stringField(req, "ticketId"), stringField(req, "ticketNum"))
+	case "/wap/address/list":
+		return ok(s.addressList())
+	case "/wap/cp/list":
+		return ok(s.cpList())
+	case "/nj/coupon/order_list":
+		return ok(map[string]interface{}{"couponList": []interface{}{}})
+	case "/nj/order/order":
+		return s.order(body)
+	case "/nj/order/getOrderResult":
+		return s.orderResult(stringField(req, "orderJobKey"))
+	case "/nj/order/coreOrder":
+		return s.orderResult(stringField(req, "coreOrderKey"))
+	}
+	return Reply{HTTPStatus: http.StatusNotFound, State: "404", Msg: "接口不存在"}
+}
+
+func (s *Server) accessToken() string {
+	return fmt.Sprintf("at-%d", s.tokenGen)
+}
+
+// tokenExpired 未登录（cusat 为 nil）的请求放行，携带旧 token 的请求视为过期
+func (s *Server) tokenExpired(cusat string) bool {
+	return cusat != "" && cusat != "nil" && cusat != s.accessToken()
+}
+
+func (s *Server) activityList(keyword string) []interface{} {
+	if keyword != "" && !strings.Contains(s.fixture.ActivityName, keyword) {
+		return []interface{}{}
+	}
+	labels := []interface{}{}
+	if s.fixture.Timed {
+		labels = append(labels, map[string]string{"name": "支持定时购票"})
+	}
+	showTime := ""
+	if len(s.fixture.Sessions) > 0 {
+		showTime = s.fixture.Sessions[0].Name
+	}
+	return []interface{}{map[string]interface{}{
+		"activityId":  s.fixture.ActivityID,
+		"title":       s.fixture.ActivityName,
+		"showTime":    showTime,
+		"siteName":    "测试场馆",
+		"otherLabels": labels,
+	}}
+}
+
+func (s *Server) ticketList() []interface{} {
+	sessions := make([]interface{}, 0, len(s.fixture.Sessions))
+	for _, session := range s.fixture.Sessions {
+		var all []interface{}
+		prices := make([]interface{}, 0, len(session.Prices))
+		for _, ticket := range session.Prices {
+			info := map[string]interface{}{
+				"ticketId":     ticket.ID,
+				"ticketType":   strconv.Itoa(ticket.TicketType),
+				"sellingPrice": ticket.Price,
+				"costPrice":    ticket.Price,
+				"saleStatus":   ticket.SaleStatus,
+				"activityId":   s.fixture.ActivityID,
+				"goodType":     1,
+				"limitBuyNum":  ticket.LimitBuyNum,
+				"canBuyNum":    ticket.CanBuyNum,
+				"remainTicket": ticket.Remain,
+				"startTime":    ticket.StartTime,
+				"sessionId":    session.ID,
+			}
+			all = append(all, info)
+			prices = append(prices, map[string]interface{}{
+				"price":      ticket.Price,
+				"ticketList": []interface{}{info},
+			})
+		}
+		sessions = append(sessions, map[string]interface{}{
+			"sessionName":                 session.Name,
+			"sessionId":                   session.ID,
+			"commonPerformerDocumentType": s.fixture.DocumentType,
+			"ticketList":                  all,
+			"ticketPriceList":             prices,
+		})
+	}
+	return sessions
+}
+
+func (s *Server) confirm(ticketID, ticketNum string) Reply {
+	session, ticket := s.fixture.findTicket(ticketID)
+	if ticket == nil {
+		return Reply{State: "0", Msg: "票档不存在"}
+	}
+	num, _ := strconv.Atoi(ticketNum)
+	if ticket.CanBuyNum > 0 && num > ticket.CanBuyNum {
+		return Reply{State: "0", Msg: LimitMsg}
+	}
+	price, _ := strconv.ParseFloat(ticket.Price, 64)
+	return ok(map[string]interface{}{
+		"orderInfoVo": map[string]interface{}{
+			"title":      s.fixture.ActivityName,
+			"sessionId":  session.ID,
+			"activityId": s.fixture.ActivityID,
+			"showTime":   session.Name,
+			"areaCode":   "86_CN",
+			"telephone":  s.fixture.Telephone,
+			"ticketPriceVo": map[string]interface{}{
+				"ticketId":     ticket.ID,
+				"ticketName":   ticket.Price,
+				"price":        price,
+				"ticketType":   ticket.TicketType,
+				"limitBuyNum":  ticket.LimitBuyNum,
+				"canBuyNum":    ticket.CanBuyNum,
+				"remainTicket": ticket.Remain,
+			},
+			"buyType":                     s.fixture.BuyType,
+			"commonPerformerDocumentType": s.fixture.DocumentType,
+		},
+	})
+}
+
+func (s *Server) addressList() []interface{} {
+	res := make([]interface{}, 0, len(s.fixture.Addresses))
+	for _, address := range s.fixture.Addresses {
+		isDefault := 0
+		if address.IsDefault {
+			isDefault = 1
+		}
+		res = append(res, map[string]interface{}{"id": address.ID, "address": address.Address, "isDefault": isDefault})
+	}
+	return res
+}
+
+func (s *Server) cpList() []interface{} {
+	res := make([]interface{}, 0, len(s.fixture.Audiences))
+	for _, audience := range s.fixture.Audiences {
+		res = append(res, map[string]interface{}{
+			"id":                 audience.ID,
+			"name":               audience.Name,
+			"canBuy":             1,
+			"documentType":       audience.DocumentType,
+			"showDocumentNumber": audience.Document,
+		})
+	}
+	return res
+}
+
+func (s *Server) order(body string) Reply {
+	var req struct {
+		OrderDetails []struct {
+			SkuID string `json:"skuId"`
+			Num   string `json:"num"`
+		} `json:"orderDetails"`
+		CommonPerfomerIds []int `json:"commonPerfomerIds"`
+	}
+	if err := json.Unmarshal([]byte(body), &req); err != nil || len(req.OrderDetails) == 0 {
+		return Reply{State: "0", Msg: "订单参数错误"}
+	}
+
+	_, ticket := s.fixture.findTicket(req.OrderDetails[0].SkuID)
+	if ticket == nil {
+		return Reply{State: "0", Msg: "票档不存在"}
+	}
+	if s.soldOut || ticket.Remain <= 0 {
+		return Reply{State: "0", Msg: SoldOutMsg}
+	}
+	if s.limitReached[ticket.ID] {
+		return Reply{State: "0", Msg: LimitMsg}
+	}
+	if s.fixture.BuyType == 2 && len(req.CommonPerfomerIds) == 0 {
+		return Reply{State: "0", Msg: "请选择观演人"}
+	}
+
+	result := map[string]interface{}{}
+	if s.sleep > 0 {
+		result["sleep"] = s.sleep
+		result["sleepExipre"] = s.sleepExpire
+		if s.sleepOnly {
+			result["orderJobKey"] = ""
+			return ok(result)
+		}
+	}
+
+	ticket.Remain--
+	s.jobSeq++
+	if s.coreOrder {
+		coreKey := fmt.Sprintf("core-%d", s.jobSeq)
+		s.pendingByJobID[coreKey] = s.pending
+		result["orderJobKey"] = ""
+		result["coreOrderKey"] = coreKey
+		return ok(result)
+	}
+	jobKey := fmt.Sprintf("job-%d", s.jobSeq)
+	s.pendingByJobID[jobKey] = s.pending
+	result["orderJobKey"] = jobKey
+	return ok(result)
+}
+
+func (s *Server) orderResult(jobKey string) Reply {
+	remain, exists := s.pendingByJobID[jobKey]
+	if !exists {
+		return Reply{State: "0", Msg: "订单不存在"}
+	}
+	if remain > 0 {
+		s.pendingByJobID[jobKey] = remain - 1
+		return Reply{State: "1", Success: true, Result: "pending"}
+	}
+
+	_, seq, _ := strings.Cut(jobKey, "-")
+	orderSn := "SN" + seq
+	if remain == 0 {
+		// 只记录一次
+		s.orders = append(s.orders, orderSn)
+		s.pendingByJobID[jobKey] = -1
+	}
+	return ok(map[string]interface{}{"orderSn": orderSn, "orderId": orderSn})
+}
+
+func (s *Server) write(w http.ResponseWriter, reply Reply) {
+	status := reply.HTTPStatus
+	if status == 0 {
+		status = http.StatusOK
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"state":   reply.State,
+		"success": reply.Success,
+		"msg":     reply.Msg,
+		"status":  status,
+		"traceId": "showstarttest",
+		"result":  reply.Result,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+func ok(result interface{}) Reply {
+	return Reply{State: "1", Success: true, Result: result}
+}
+
+func stringField(m map[string]interface{}, key string) string {
+	switch v := m[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// verifySign 按照 header 中的取值重新计算 crpsign
+func verifySign(path string, h http.Header, body string) bool {
+	return util.GenerateSign(util.SignReqFromHeader(path, body, h)) == h.Get("crpsign")
+}
+
+// decodeBody 解密 {"q":...} 形式的请求体
+func decodeBody(path string, h http.Header, body string) (string, error) {
+	if !vars.EncryptPathMap[path] {
+		return body, nil
+	}
+	return util.DecryptRequestBody(body, h.Get("crtraceid"), h.Get("cdeviceno"))
+}
@@ -0,0 +1,187 @@
+package showstarttest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/staparx/go_showstart/client"
+	"github.com/staparx/go_showstart/client/showstarttest"
+	"github.com/staparx/go_showstart/config"
+)
+
+// fastPoll 测试中缩短 pending 轮询间隔
+var fastPoll = client.RetryPolicy{
+	MaxAttempts: 20,
+	BaseDelay:   time.Millisecond,
+	MaxDelay:    time.Millisecond,
+	Multiplier:  1,
+	Retryable:   client.DefaultPollPolicy.Retryable,
+}
+
+func newClient(t *testing.T, s *showstarttest.Server) client.ShowStartIface {
+	t.Helper()
+	cfg := &config.Showstart{
+		Sign:      "sig",
+		Token:     "abcdefghijklmnopqrstuvwxyz0123456789",
+		Cterminal: "wap",
+		Cversion:  "997",
+		Cusid:     "1",
+		Cusname:   "nil",
+		BaseURL:   s.BaseURL,
+	}
+	c := client.NewShowStartClient(cfg, client.WithRateLimiter(nil), client.WithPollPolicy(fastPoll))
+	if err := c.GetToken(context.Background()); err != nil {
+		t.Fatalf("GetToken: %v", err)
+	}
+	return c
+}
+
+func orderReq(ticketID string) *client.OrderReq {
+	return &client.OrderReq{
+		OrderDetails:      []*client.OrderDetail{{GoodsType: 1, SkuType: 1, Num: "1", GoodsID: 123456, SkuID: ticketID, Price: 388}},
+		CommonPerfomerIds: []int{5773864},
+		SessionID:         3249212,
+	}
+}
+
+func TestPendingThenOrderResult(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	c := newClient(t, s)
+	ctx := context.Background()
+
+	s.SetPending(3)
+	resp, err := c.Order(ctx, orderReq("ticket-388"))
+	if err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+	if resp.Result.OrderJobKey == "" {
+		t.Fatal("Order 未返回 orderJobKey")
+	}
+
+	result, err := c.GetOrderResult(ctx, resp.Result.OrderJobKey)
+	if err != nil {
+		t.Fatalf("GetOrderResult: %v", err)
+	}
+	if result.Result.OrderSn != "SN1" {
+		t.Errorf("orderSn = %q, want SN1", result.Result.OrderSn)
+	}
+	// 3 次 pending 加 1 次成功
+	if n := s.Count("/nj/order/getOrderResult"); n != 4 {
+		t.Errorf("getOrderResult 请求 %d 次, want 4", n)
+	}
+	if n := s.SignFailures(); n != 0 {
+		t.Errorf("crpsign 校验失败 %d 次", n)
+	}
+}
+
+func TestPendingExhaustsPollPolicy(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	c := newClient(t, s)
+	ctx := context.Background()
+
+	s.SetPending(fastPoll.MaxAttempts + 5)
+	resp, err := c.Order(ctx, orderReq("ticket-388"))
+	if err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+	if _, err := c.GetOrderResult(ctx, resp.Result.OrderJobKey); err == nil {
+		t.Fatal("pending 超过轮询次数时应返回错误")
+	}
+	if n := s.Count("/nj/order/getOrderResult"); n != fastPoll.MaxAttempts {
+		t.Errorf("getOrderResult 请求 %d 次, want %d", n, fastPoll.MaxAttempts)
+	}
+}
+
+func TestTokenExpiredRefresh(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	c := newClient(t, s)
+
+	s.ExpireToken()
+	detail, err := c.ActivityDetail(context.Background(), 123456)
+	if err != nil {
+		t.Fatalf("ActivityDetail: %v", err)
+	}
+	if detail.Result.ActivityName != s.Fixture().ActivityName {
+		t.Errorf("activityName = %q", detail.Result.ActivityName)
+	}
+	if n := s.Count("/waf/gettoken"); n != 2 {
+		t.Errorf("gettoken 请求 %d 次, want 2", n)
+	}
+	// 过期请求 + 刷新后的重试
+	if n := s.Count("/wap/activity/details"); n != 2 {
+		t.Errorf("details 请求 %d 次, want 2", n)
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	c := newClient(t, s)
+	ctx := context.Background()
+
+	s.SetThrottle("/nj/order/order", 1)
+	_, err := c.Order(ctx, orderReq("ticket-388"))
+	if !client.IsThrottled(err) {
+		t.Fatalf("第一次下单 err = %v, want 限流", err)
+	}
+	if _, err := c.Order(ctx, orderReq("ticket-388")); err != nil {
+		t.Fatalf("限流结束后下单: %v", err)
+	}
+}
+
+func TestSoldOutAndLimit(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	c := newClient(t, s)
+	ctx := context.Background()
+
+	s.SetRemain("ticket-388", 0)
+	_, err := c.Order(ctx, orderReq("ticket-388"))
+	if !client.IsSoldOut(err) {
+		t.Fatalf("err = %v, want 售罄", err)
+	}
+
+	s.SetLimitReached("ticket-588", true)
+	_, err = c.Order(ctx, orderReq("ticket-588"))
+	if !client.IsLimitReached(err) {
+		t.Fatalf("err = %v, want 限购", err)
+	}
+	if client.IsSoldOut(err) {
+		t.Error("限购不应判断为售罄")
+	}
+}
+
+func TestCoreOrder(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	c := newClient(t, s)
+	ctx := context.Background()
+
+	s.SetCoreOrder(true)
+	s.SetPending(2)
+	resp, err := c.Order(ctx, orderReq("ticket-388"))
+	if err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+	if resp.Result.OrderJobKey != "" || resp.Result.CoreOrderKey == "" {
+		t.Fatalf("orderJobKey = %q, coreOrderKey = %q", resp.Result.OrderJobKey, resp.Result.CoreOrderKey)
+	}
+
+	core, err := c.CoreOrder(ctx, resp.Result.CoreOrderKey)
+	if err != nil {
+		t.Fatalf("CoreOrder: %v", err)
+	}
+	if core.Result.OrderSn != "SN1" {
+		t.Errorf("orderSn = %q, want SN1", core.Result.OrderSn)
+	}
+	if n := s.Count("/nj/order/coreOrder"); n != 3 {
+		t.Errorf("coreOrder 请求 %d 次, want 3", n)
+	}
+	if orders := s.Orders(); len(orders) != 1 {
+		t.Errorf("服务端订单 %v, want 1 个", orders)
+	}
+}
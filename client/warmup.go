@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// head 向接口地址发送一次 HEAD 请求，不经过拦截器与限流，只用于对时与预热连接
+func (c *ShowStartClient) head(ctx context.Context) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.BashUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	// 读完响应体后连接才会放回连接池
+	_, _ = io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	return res.Header, nil
+}
+
+// Warmup 并发发送 conns 个 HEAD 请求，建立并保持空闲连接，开售时下单请求不再等待 DNS、TLS 握手与建连。
+// 返回成功的请求数，全部失败时返回最后一个错误
+func (c *ShowStartClient) Warmup(ctx context.Context, conns int) (int, error) {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		ok      int
+		lastErr error
+	)
+	for i := 0; i < conns; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.head(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				lastErr = err
+				return
+			}
+			ok++
+		}()
+	}
+	wg.Wait()
+	if ok == 0 {
+		return 0, lastErr
+	}
+	return ok, nil
+}
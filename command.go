@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command 子命令，返回进程退出码
+type command struct {
+	usage string
+	run   func(args []string) int
+}
+
+var commands = map[string]*command{
+	"check":      {usage: "按 config.yaml 执行开售前检查，未通过时退出码为 1", run: runCheck},
+	"decode":     {usage: "解密抓包得到的 {\"q\":...} 请求体并校验 crpsign", run: runDecode},
+	"fakeserver": {usage: "启动秀动接口模拟服务，配合 dry_run: send 演练抢票流程", run: runFakeServer},
+}
+
+// runCommand 执行子命令，args[0] 不是已知子命令时返回 false
+func runCommand(args []string) (int, bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage()
+		return 0, true
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return 0, false
+	}
+	return cmd.run(args[1:]), true
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "用法：go_showstart [子命令] [参数]")
+	fmt.Fprintln(os.Stderr, "不带子命令时按 config.yaml 进入抢票或监控模式。")
+	fmt.Fprintln(os.Stderr, "子命令：")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].usage)
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"regexp"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -23,6 +23,37 @@ type System struct {
 	MaxGoroutine int `mapstructure:"max_goroutine"`
 	MinInterval  int `mapstructure:"min_interval"`
 	MaxInterval  int `mapstructure:"max_interval"`
+	// ClockSyncSamples 开售前通过服务端 Date 头对时的采样次数，默认 8，为负数时不对时
+	ClockSyncSamples int `mapstructure:"clock_sync_samples"`
+	// PrewarmSeconds 开售前多少秒开始预热连接，默认 5，为负数时不预热
+	PrewarmSeconds int `mapstructure:"prewarm_seconds"`
+	// LeadMs 提前多少毫秒发出第一个下单请求，用于抵消网络单程耗时，默认 0
+	LeadMs int `mapstructure:"lead_ms"`
+	// Interactive 是否允许在终端中交互输入（手动匹配票档、退出前按回车），不配置时根据标准输入是否为终端判断
+	Interactive *bool `mapstructure:"interactive"`
+	// UnsafeLogPlaintext 关闭日志脱敏，明文输出 token、手机号、证件号，仅用于本地调试
+	UnsafeLogPlaintext bool `mapstructure:"unsafe_log_plaintext"`
+}
+
+// IsInteractive 是否允许交互输入；Docker、Railway 等没有终端的环境默认不交互
+func (s *System) IsInteractive() bool {
+	if s != nil && s.Interactive != nil {
+		return *s.Interactive
+	}
+	return StdinIsTerminal()
+}
+
+// StdinIsTerminal 标准输入是否为终端
+func StdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	// docker run 不加 -i 时标准输入是 /dev/null，同样是字符设备
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(info, null) {
+		return false
+	}
+	return true
 }
 
 type Showstart struct {
@@ -35,6 +66,16 @@ type Showstart struct {
 	Cversion    string `mapstructure:"cversion"`
 	Cterminal   string `mapstructure:"cterminal"`
 	Cdeviceinfo string `mapstructure:"cdeviceinfo"`
+	// BaseURL 接口地址，留空使用线上地址，可指向 showstarttest 模拟服务
+	BaseURL string `mapstructure:"base_url"`
+	// RecordFile 录制请求/响应到该文件（JSON Lines），敏感信息脱敏
+	RecordFile string `mapstructure:"record_file"`
+	// ReplayFile 从录制文件回放响应，不访问网络
+	ReplayFile string `mapstructure:"replay_file"`
+	// AuditFile 审计日志文件（JSON Lines），记录每次请求的脱敏请求体、返回状态与耗时
+	AuditFile string `mapstructure:"audit_file"`
+	// SchemaDrift 诊断模式，比对接口返回与类型定义，记录新增、缺失与类型变化的字段
+	SchemaDrift bool `mapstructure:"schema_drift"`
 }
 
 type Ticket struct {
@@ -42,11 +83,105 @@ type Ticket struct {
 	StartTime  string       `mapstructure:"start_time"`
 	List       []TicketList `mapstructure:"list"`
 	People     []string     `mapstructure:"people"`
+	Strategy   Strategy     `mapstructure:"strategy"`
+	MaxOrders  int          `mapstructure:"max_orders"`
+	// DryRun 演练模式，对所有任务生效：log 只输出下单请求，send 向 base_url 指向的模拟服务下单
+	DryRun DryRun `mapstructure:"dry_run"`
+	// PrecheckMinutes 开售前多少分钟执行检查，如 [30, 5]，未通过时发送邮件提醒
+	PrecheckMinutes []int `mapstructure:"precheck_minutes"`
+	// Jobs 多个抢票任务，各自独立调度；为空时使用上面的单个任务配置
+	Jobs []*TicketJob `mapstructure:"jobs"`
+}
+
+// TicketJob 一个抢票任务
+type TicketJob struct {
+	Name       string       `mapstructure:"name"`
+	ActivityId int          `mapstructure:"activity_id"`
+	StartTime  string       `mapstructure:"start_time"`
+	List       []TicketList `mapstructure:"list"`
+	People     []string     `mapstructure:"people"`
+	// Strategy 多个票档之间的抢购策略，默认 priority
+	Strategy Strategy `mapstructure:"strategy"`
+	// MaxOrders parallel 策略下成功下单的数量上限，默认 1
+	MaxOrders int `mapstructure:"max_orders"`
+	// Legacy 由 ticket 下的单个任务配置转换而来，手动匹配的结果可以写回配置文件
+	Legacy bool `mapstructure:"-"`
+}
+
+// JobList 返回所有抢票任务，未配置 jobs 时将旧的单任务配置作为唯一任务
+func (t *Ticket) JobList() []*TicketJob {
+	if t == nil {
+		return nil
+	}
+	if len(t.Jobs) > 0 {
+		for i, job := range t.Jobs {
+			if job != nil && job.Name == "" {
+				job.Name = fmt.Sprintf("job-%d", i+1)
+			}
+		}
+		return t.Jobs
+	}
+	if len(t.List) == 0 {
+		return nil
+	}
+	return []*TicketJob{{
+		Name:       fmt.Sprintf("%d", t.ActivityId),
+		ActivityId: t.ActivityId,
+		StartTime:  t.StartTime,
+		List:       t.List,
+		People:     t.People,
+		Strategy:   t.Strategy,
+		MaxOrders:  t.MaxOrders,
+		Legacy:     true,
+	}}
 }
 
+// Strategy 多个票档之间的抢购策略
+type Strategy string
+
+const (
+	// StrategyPriority 按配置顺序逐个抢购，当前票档售罄或达到限购时切换到下一个
+	StrategyPriority Strategy = "priority"
+	// StrategyParallel 所有票档同时抢购，成功 max_orders 个订单后停止
+	StrategyParallel Strategy = "parallel"
+	// StrategyCheapest 按价格从低到高逐个抢购，售罄或达到限购时切换到下一个
+	StrategyCheapest Strategy = "cheapest"
+)
+
+// Valid 是否为支持的策略，空值按 priority 处理
+func (s Strategy) Valid() bool {
+	switch s {
+	case "", StrategyPriority, StrategyParallel, StrategyCheapest:
+		return true
+	}
+	return false
+}
+
+// OrDefault 为空时返回 priority
+func (s Strategy) OrDefault() Strategy {
+	if s == "" {
+		return StrategyPriority
+	}
+	return s
+}
+
+// DryRun 演练模式
+type DryRun string
+
+const (
+	// DryRunOff 正常抢票
+	DryRunOff DryRun = ""
+	// DryRunLog 完成下单前的所有步骤，到抢票时间只输出下单请求，不调用下单接口
+	DryRunLog DryRun = "log"
+	// DryRunSend 完整执行抢票流程，只允许向 base_url 指向的模拟服务下单
+	DryRunSend DryRun = "send"
+)
+
 type TicketList struct {
 	Session string `mapstructure:"session"`
 	Price   string `mapstructure:"price"`
+	// StartTime 该票档的抢票时间，票档开售时间不同时配置，为空时使用任务的 start_time
+	StartTime string `mapstructure:"start_time"`
 }
 
 type smtp_email struct {
@@ -114,55 +249,30 @@ func InitCfg() (*Config, error) {
 
 	return cfg, nil
 }
-
-// 保存手动匹配信息到config.yaml
-func SaveCfg(SessionName string, Price string) error {
-	filename := "config.yaml"
-
-	// 以文本读取
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return err
+// CheckDryRun 检查演练模式：dry_run 为 send 时 base_url 必须指向模拟服务，避免真实下单
+func (cfg *Config) CheckDryRun() error {
+	if cfg.Ticket == nil {
+		return nil
 	}
-
-	// 将文件内容转换为字符串
-	content := string(data)
-
-	// 使用正则表达式定位 session:.*\n 的位置 并替换第一个匹配项
-	re := regexp.MustCompile(`(?m)session:.*\n`)
-	replaced := false
-	content = re.ReplaceAllStringFunc(content, func(match string) string {
-		if !replaced {
-			replaced = true
-			return fmt.Sprintf("session: \"%s\"\n", SessionName)
+	switch cfg.Ticket.DryRun {
+	case DryRunOff, DryRunLog:
+	case DryRunSend:
+		if cfg.Showstart == nil || cfg.Showstart.BaseURL == "" || strings.Contains(cfg.Showstart.BaseURL, "showstart.com") {
+			return errors.New("dry_run 为 send 时需将 showstart.base_url 指向模拟服务，避免真实下单")
 		}
-		return match
-	})
-
-	// 使用正则表达式定位 price: "" 的位置 并替换第一个匹配项
-	re = regexp.MustCompile(`(?m)price:.*\n`)
-	replaced = false
-	content = re.ReplaceAllStringFunc(content, func(match string) string {
-		if !replaced {
-			replaced = true
-			return fmt.Sprintf("price: \"%s\"\n", Price)
-		}
-		return match
-	})
-
-	// 将修改后的内容写回文件
-	err = os.WriteFile(filename, []byte(content), 0644)
-	if err != nil {
-		return err
+	default:
+		return fmt.Errorf("ticket.dry_run 不支持：%s，可选 log、send", cfg.Ticket.DryRun)
 	}
-
 	return nil
-
 }
 
 func (cfg *Config) Validate() error {
+	if cfg.Showstart != nil && cfg.Showstart.RecordFile != "" && cfg.Showstart.ReplayFile != "" {
+		return errors.New("record_file 与 replay_file 不能同时配置")
+	}
+
 	monitorEnabled := cfg.Monitor != nil && cfg.Monitor.Enable
-	ticketEnabled := cfg.Ticket != nil && len(cfg.Ticket.List) > 0
+	ticketEnabled := len(cfg.Ticket.JobList()) > 0
 
 	if !monitorEnabled && !ticketEnabled {
 		return errors.New("配置中未开启监控或抢票功能，请至少启用一项")
@@ -173,8 +283,43 @@ func (cfg *Config) Validate() error {
 			return errors.New("未读取到票务配置信息")
 		}
 
-		if len(cfg.Ticket.People) == 0 {
-			return errors.New("未读取到观演人信息")
+		for _, minutes := range cfg.Ticket.PrecheckMinutes {
+			if minutes <= 0 {
+				return errors.New("ticket.precheck_minutes 需为正整数")
+			}
+		}
+
+		if err := cfg.CheckDryRun(); err != nil {
+			return err
+		}
+
+		names := map[string]bool{}
+		for i, job := range cfg.Ticket.JobList() {
+			if job == nil {
+				return fmt.Errorf("ticket.jobs 第 %d 项为空", i+1)
+			}
+			if names[job.Name] {
+				return fmt.Errorf("抢票任务名称重复：%s", job.Name)
+			}
+			names[job.Name] = true
+			if job.ActivityId == 0 {
+				return fmt.Errorf("抢票任务 %s 未配置 activity_id", job.Name)
+			}
+			if len(job.List) == 0 {
+				return fmt.Errorf("抢票任务 %s 未配置票档 list", job.Name)
+			}
+			if len(job.People) == 0 {
+				return fmt.Errorf("抢票任务 %s 未读取到观演人信息", job.Name)
+			}
+			if !job.Strategy.Valid() {
+				return fmt.Errorf("抢票任务 %s 的 strategy 不支持：%s，可选 priority、parallel、cheapest", job.Name, job.Strategy)
+			}
+			if job.MaxOrders < 0 {
+				return fmt.Errorf("抢票任务 %s 的 max_orders 不能为负数", job.Name)
+			}
+			if job.MaxOrders > 1 && job.Strategy.OrDefault() != StrategyParallel {
+				return fmt.Errorf("抢票任务 %s 的 max_orders 仅在 parallel 策略下生效", job.Name)
+			}
 		}
 	}
 
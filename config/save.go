@@ -0,0 +1,179 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// SaveCfg 保存手动匹配的场次与票价到 ticket.list 的第一项，写入 viper 实际加载的配置文件
+func SaveCfg(SessionName string, Price string) error {
+	filename := viper.ConfigFileUsed()
+	if filename == "" {
+		return errors.New("未加载配置文件，无法保存配置")
+	}
+	return UpdateCfgFile(filename, func(root *yaml.Node) error {
+		list := mappingValue(ensureMapping(root, "ticket"), "list")
+		if list == nil {
+			list = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			setMappingValue(ensureMapping(root, "ticket"), "list", list)
+		}
+		if list.Kind != yaml.SequenceNode {
+			return errors.New("ticket.list 不是列表")
+		}
+		if len(list.Content) == 0 {
+			list.Content = append(list.Content, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+		}
+		item := list.Content[0]
+		if item.Kind != yaml.MappingNode {
+			return errors.New("ticket.list 的第一项不是对象")
+		}
+		setMappingValue(item, "session", stringNode(SessionName))
+		setMappingValue(item, "price", stringNode(Price))
+		return nil
+	})
+}
+
+// UpdateCfgFile 以 yaml 节点读取配置文件，由 update 修改后写回，保留注释与字段顺序。
+// 写入先落到同目录的临时文件再重命名，避免写入中断时损坏配置文件
+func UpdateCfgFile(filename string, update func(root *yaml.Node) error) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("解析配置文件 %s 失败：%w", filename, err)
+	}
+	if doc.Kind == 0 { // 空文件
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("配置文件 %s 的顶层不是对象", filename)
+	}
+	if err := update(doc.Content[0]); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, restoreBlankLines(data, buf.Bytes()))
+}
+
+// restoreBlankLines yaml 编码会丢弃空行，非空行数不变时（只修改了值）按原文件的位置补回空行
+func restoreBlankLines(orig, out []byte) []byte {
+	origLines := bytes.Split(bytes.TrimRight(orig, "\n"), []byte("\n"))
+	outLines := bytes.Split(bytes.TrimRight(out, "\n"), []byte("\n"))
+
+	nonBlank := 0
+	for _, line := range origLines {
+		if len(bytes.TrimSpace(line)) > 0 {
+			nonBlank++
+		}
+	}
+	if nonBlank != len(outLines) {
+		return out
+	}
+
+	var buf bytes.Buffer
+	next := 0
+	for _, line := range origLines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			buf.WriteByte('\n')
+			continue
+		}
+		buf.Write(outLines[next])
+		buf.WriteByte('\n')
+		next++
+	}
+	return buf.Bytes()
+}
+
+// writeFileAtomic 写入临时文件后重命名替换 filename，保留原文件权限
+func writeFileAtomic(filename string, data []byte) error {
+	// 配置文件是软链接时替换链接指向的文件
+	if target, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = target
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // 重命名成功后不存在，忽略错误
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, mode); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, filename)
+}
+
+// mappingValue 返回对象中 key 对应的值节点，不存在时返回 nil
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue 设置对象中 key 的值：已存在时保留原节点的注释，否则追加到末尾
+func setMappingValue(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			old := m.Content[i+1]
+			value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// ensureMapping 返回对象中 key 对应的子对象，不存在或为空值时新建
+func ensureMapping(m *yaml.Node, key string) *yaml.Node {
+	if v := mappingValue(m, key); v != nil && v.Kind == yaml.MappingNode {
+		return v
+	}
+	v := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	setMappingValue(m, key, v)
+	return v
+}
+
+// stringNode 带引号的字符串节点，避免 388 之类的票价被当作数字
+func stringNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Style: yaml.DoubleQuotedStyle, Value: value}
+}
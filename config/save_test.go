@@ -0,0 +1,166 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// useCfgFile 将 data 写入临时目录中的配置文件，并作为 viper 加载的配置文件
+func useCfgFile(t *testing.T, data string, mode os.FileMode) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(filename, []byte(data), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filename, mode); err != nil {
+		t.Fatal(err)
+	}
+	viper.SetConfigFile(filename)
+	t.Cleanup(func() { viper.SetConfigFile("") })
+	return filename
+}
+
+// readTicketList 读取 ticket.list
+func readTicketList(t *testing.T, filename string) []TicketList {
+	t.Helper()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg struct {
+		Ticket struct {
+			List []TicketList `yaml:"list"`
+		} `yaml:"ticket"`
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("保存后的配置文件无法解析：%v\n%s", err, data)
+	}
+	return cfg.Ticket.List
+}
+
+func TestSaveCfgExampleRoundTrip(t *testing.T) {
+	example, err := os.ReadFile("../config.example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := useCfgFile(t, string(example), 0600)
+
+	if err := SaveCfg("2024-08-17 周六 20:00", "588"); err != nil {
+		t.Fatalf("SaveCfg: %v", err)
+	}
+	saved, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 只有 ticket.list 第一项的 session 与 price 两行变化，注释与空行原样保留
+	before := strings.Split(string(example), "\n")
+	after := strings.Split(string(saved), "\n")
+	if len(before) != len(after) {
+		t.Fatalf("行数 %d → %d:\n%s", len(before), len(after), saved)
+	}
+	var changed []string
+	for i := range before {
+		if before[i] != after[i] {
+			changed = append(changed, after[i])
+		}
+	}
+	want := []string{`    - session: "2024-08-17 周六 20:00"`, `      price: "588"`}
+	if strings.Join(changed, "\n") != strings.Join(want, "\n") {
+		t.Errorf("变化的行 = %q, want %q", changed, want)
+	}
+	if list := readTicketList(t, filename); len(list) != 1 || list[0].Session != "2024-08-17 周六 20:00" || list[0].Price != "588" {
+		t.Errorf("ticket.list = %+v", list)
+	}
+}
+
+func TestSaveCfgCreatesList(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"空文件", ""},
+		{"没有 ticket", "showstart:\n  sign: \"abc\"\n"},
+		{"没有 list", "ticket:\n  activity_id: 123456\n"},
+		{"list 为空", "ticket:\n  activity_id: 123456\n  list: []\n"},
+		{"ticket 为空值", "ticket:\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := useCfgFile(t, tt.data, 0644)
+			if err := SaveCfg("2024-08-16 周五 20:00", "388"); err != nil {
+				t.Fatalf("SaveCfg: %v", err)
+			}
+			list := readTicketList(t, filename)
+			if len(list) != 1 || list[0].Session != "2024-08-16 周五 20:00" || list[0].Price != "388" {
+				t.Errorf("ticket.list = %+v", list)
+			}
+		})
+	}
+
+	useCfgFile(t, "ticket:\n  list: \"388\"\n", 0644)
+	if err := SaveCfg("2024-08-16 周五 20:00", "388"); err == nil {
+		t.Error("ticket.list 不是列表时应返回错误")
+	}
+}
+
+func TestSaveCfgSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "real.yaml")
+	if err := os.WriteFile(target, []byte("ticket:\n  list:\n    - session: \"a\"\n      price: \"1\"\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "config.yaml")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("不支持软链接：%v", err)
+	}
+	viper.SetConfigFile(link)
+	defer viper.SetConfigFile("")
+
+	if err := SaveCfg("b", "2"); err != nil {
+		t.Fatalf("SaveCfg: %v", err)
+	}
+	// 软链接保持不变，写入链接指向的文件
+	if dest, err := os.Readlink(link); err != nil || dest != target {
+		t.Fatalf("Readlink = %q, %v", dest, err)
+	}
+	if list := readTicketList(t, target); len(list) != 1 || list[0].Session != "b" || list[0].Price != "2" {
+		t.Errorf("ticket.list = %+v", list)
+	}
+	// 没有残留的临时文件
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("目录中有 %d 个文件", len(entries))
+	}
+}
+
+func TestSaveCfgKeepsFileMode(t *testing.T) {
+	for _, mode := range []os.FileMode{0600, 0640, 0644} {
+		filename := useCfgFile(t, "ticket:\n  list: []\n", mode)
+		if err := SaveCfg("a", "1"); err != nil {
+			t.Fatalf("SaveCfg: %v", err)
+		}
+		info, err := os.Stat(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("mode = %v, want %v", info.Mode().Perm(), mode)
+		}
+	}
+}
+
+func TestSaveCfgWithoutConfigFile(t *testing.T) {
+	viper.SetConfigFile("")
+	if err := SaveCfg("a", "1"); err == nil {
+		t.Error("未加载配置文件时应返回错误")
+	}
+}
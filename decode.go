@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/staparx/go_showstart/util"
+)
+
+// runDecode 解密抓包得到的请求体，并根据请求头重新计算 crpsign 进行比对
+func runDecode(args []string) int {
+	fs := flag.NewFlagSet("decode", flag.ContinueOnError)
+	headersFile := fs.String("headers", "", "抓包得到的请求头文件，每行 \"name: value\"，或 JSON 对象")
+	body := fs.String("body", "", "请求体，{\"q\":...} 或明文")
+	bodyFile := fs.String("body-file", "", "从文件读取请求体")
+	traceId := fs.String("trace", "", "crtraceid，未指定时从请求头读取")
+	token := fs.String("token", "", "token（即 cdeviceno），未指定时从请求头读取")
+	path := fs.String("path", "", "请求路径，如 /nj/order/order 或完整 URL，用于校验 crpsign")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	header := http.Header{}
+	if *headersFile != "" {
+		var err error
+		header, err = readHeaders(*headersFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "读取请求头失败：", err)
+			return 2
+		}
+	}
+	if *traceId == "" {
+		*traceId = header.Get("crtraceid")
+	}
+	if *token == "" {
+		*token = header.Get("cdeviceno")
+	}
+
+	data := *body
+	if *bodyFile != "" {
+		raw, err := os.ReadFile(*bodyFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "读取请求体失败：", err)
+			return 2
+		}
+		data = strings.TrimSpace(string(raw))
+	}
+	if data == "" {
+		fmt.Fprintln(os.Stderr, "请通过 -body 或 -body-file 指定请求体")
+		fs.Usage()
+		return 2
+	}
+
+	code := 0
+
+	plain := data
+	if isEncryptedBody(data) {
+		if *traceId == "" || *token == "" {
+			fmt.Fprintln(os.Stderr, "解密需要 traceId 与 token，请通过 -trace/-token 或 -headers 指定")
+			return 2
+		}
+		var err error
+		plain, err = util.DecryptRequestBody(data, *traceId, *token)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "❌ 解密失败：", err)
+			return 1
+		}
+		fmt.Println("🔓 解密后的请求体：")
+	} else {
+		fmt.Println("📄 请求体未加密：")
+	}
+	fmt.Println(prettyJSON(plain))
+
+	if *path != "" && header.Get("crpsign") != "" {
+		apiPath := trimAPIPath(*path)
+		signReq := util.SignReqFromHeader(apiPath, data, header)
+		signReq.TraceId = *traceId
+		signReq.Token = *token
+		expected := util.GenerateSign(signReq)
+		if expected == header.Get("crpsign") {
+			fmt.Printf("✅ crpsign 校验通过：%s\n", expected)
+		} else {
+			fmt.Printf("❌ crpsign 不一致：抓包为 %s，重新计算为 %s（path=%s）\n", header.Get("crpsign"), expected, apiPath)
+			code = 1
+		}
+	} else if *path != "" || header.Get("crpsign") != "" {
+		fmt.Println("⚠️ 校验 crpsign 需要同时提供 -path 与包含 crpsign 的 -headers")
+	}
+
+	return code
+}
+
+func isEncryptedBody(body string) bool {
+	var wrapped map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &wrapped); err != nil {
+		return false
+	}
+	_, ok := wrapped["q"]
+	return ok && len(wrapped) == 1
+}
+
+// readHeaders 读取请求头，支持浏览器复制的 "name: value" 文本或 JSON 对象
+func readHeaders(file string) (http.Header, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var m map[string]string
+		if err := json.Unmarshal(trimmed, &m); err != nil {
+			return nil, err
+		}
+		for k, v := range m {
+			header.Set(k, v)
+		}
+		return header, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// 兼容 HTTP/2 的伪头部，如 ":path: /v3/..."
+		if strings.HasPrefix(line, ":") {
+			line = line[1:]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		header.Set(strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+	return header, scanner.Err()
+}
+
+// trimAPIPath 去掉域名与 /v3 前缀
+func trimAPIPath(p string) string {
+	if idx := strings.Index(p, "/v3/"); idx >= 0 {
+		p = p[idx+len("/v3"):]
+	}
+	if idx := strings.Index(p, "?"); idx >= 0 {
+		p = p[:idx]
+	}
+	return p
+}
+
+func prettyJSON(s string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(s), "", "  "); err != nil {
+		return s
+	}
+	return buf.String()
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/staparx/go_showstart/config"
+	"gopkg.in/gomail.v2"
+)
+
+// 发送邮件
+func sendEmail(subject, body string, cfg *config.Config) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", cfg.SmtpEmail.Username)
+	m.SetHeader("To", cfg.SmtpEmail.To)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/plain", body)
+
+	d := gomail.NewDialer(cfg.SmtpEmail.Host, 587, cfg.SmtpEmail.Username, cfg.SmtpEmail.Password)
+
+	// 发送邮件
+	if err := d.DialAndSend(m); err != nil {
+		return err
+	}
+	return nil
+}
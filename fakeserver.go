@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/staparx/go_showstart/client/showstarttest"
+)
+
+// runFakeServer 启动秀动接口模拟服务，下单总是成功，配合 dry_run: send 演练完整抢票流程
+func runFakeServer(args []string) int {
+	fs := flag.NewFlagSet("fakeserver", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:8990", "监听地址")
+	saleIn := fs.Duration("sale-in", 0, "票档在多久之后开售，如 2m；为 0 时不返回开售时间，需在配置中填写 start_time")
+	pending := fs.Int("pending", 0, "查询订单结果时先返回几次 pending")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "监听失败：", err)
+		return 1
+	}
+	s := showstarttest.NewUnstartedServer(nil)
+	s.Listener.Close()
+	s.Listener = ln
+	fixture := s.Fixture()
+	if *saleIn > 0 {
+		start := time.Now().Add(*saleIn).UnixMilli()
+		for _, session := range fixture.Sessions {
+			for _, ticket := range session.Prices {
+				ticket.StartTime = start
+			}
+		}
+	}
+	s.SetPending(*pending)
+	s.Start()
+	defer s.Close()
+
+	fmt.Printf("模拟服务已启动，在配置中设置 showstart.base_url: %q\n", s.BaseURL)
+	fmt.Printf("活动：%d %s\n", fixture.ActivityID, fixture.ActivityName)
+	for _, session := range fixture.Sessions {
+		for _, ticket := range session.Prices {
+			fmt.Printf("  session: %q price: %q\n", session.Name, ticket.Price)
+		}
+	}
+	for _, audience := range fixture.Audiences {
+		fmt.Printf("观演人：%s\n", audience.Name)
+	}
+	fmt.Println("按 Ctrl+C 退出")
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+	fmt.Printf("已下单：%v\n", s.Orders())
+	return 0
+}
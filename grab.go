@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/staparx/go_showstart/client"
+	"github.com/staparx/go_showstart/config"
+	"github.com/staparx/go_showstart/grabber"
+	"github.com/staparx/go_showstart/log"
+	"github.com/staparx/go_showstart/vars"
+	"go.uber.org/zap"
+)
+
+// runGrab 并发执行所有抢票任务，每个任务独立调度、通知，全部结束后返回
+func runGrab(ctx context.Context, cfg *config.Config) {
+	jobs := cfg.Ticket.JobList()
+	if len(jobs) > 1 {
+		log.Logger.Info(fmt.Sprintf("📋共 %d 个抢票任务，将同时进行", len(jobs)))
+	}
+
+	// 所有任务共用一个 client，共享登录 token 与限流额度
+	c := client.NewShowStartClient(cfg.Showstart)
+
+	succeeded := grabber.RunJobs(ctx, cfg, c, func(job *config.TicketJob, result grabber.Result, err error) {
+		reportResult(ctx, cfg, job, result, err)
+	}, grabber.WithPrecheckHandler(func(report grabber.Report) {
+		reportPrecheck(cfg, report)
+	}))
+
+	if len(jobs) > 1 {
+		log.Logger.Info(fmt.Sprintf("📋抢票任务全部结束：成功 %d 个，共 %d 个", succeeded, len(jobs)))
+	}
+}
+
+// reportResult 记录单个任务的结果并发送邮件提醒
+func reportResult(ctx context.Context, cfg *config.Config, job *config.TicketJob, result grabber.Result, err error) {
+	logger := log.Logger.With(zap.String("job", job.Name))
+	switch {
+	case err == nil && result.DryRun:
+		for _, placed := range result.Orders {
+			logger.Info("🧪演练完成", zap.String("session", placed.Order.SessionName), zap.String("price", placed.Order.Price), zap.String("orderSn", placed.OrderSn))
+		}
+	case err == nil:
+		for _, placed := range result.Orders {
+			logger.Info("🎉抢票成功！赶紧去订单页面支付吧！！🎉", zap.String("orderSn", placed.OrderSn), zap.String("session", placed.Order.SessionName), zap.String("price", placed.Order.Price))
+		}
+		// 下单成功，发送邮件提醒
+		if cfg.SmtpEmail.Enable {
+			subject := vars.GetEmailTitle()
+
+			bodies := make([]string, 0, len(result.Orders))
+			for _, placed := range result.Orders {
+				bodies = append(bodies, vars.GetEmailFormat(placed.Order.ActivityName, placed.Order.SessionName, placed.Order.Price))
+			}
+			body := strings.Join(bodies, "\n")
+
+			if err := sendEmail(subject, body, cfg); err != nil {
+				logger.Error("发送邮件失败：", zap.Error(err))
+			} else {
+				logger.Info("下单成功，邮件已发送")
+			}
+		}
+	case errors.Is(err, grabber.ErrSetup):
+		logger.Error("❌ 抢票失败！！！任务结束", zap.Error(err))
+	case ctx.Err() != nil:
+		logger.Info("⚠️ 接收到关闭信号，任务结束")
+	default:
+		logger.Error("❌ 抢票失败！！！任务结束", zap.Error(err))
+		// 下单失败，发送邮件提醒
+		if cfg.SmtpEmail.Enable {
+			subject := fmt.Sprintf("抢票任务 %s 失败，请查看错误，并及时处理重启程序！！！", job.Name)
+
+			body := fmt.Sprintf("错误信息：%s", err.Error())
+
+			if err := sendEmail(subject, body, cfg); err != nil {
+				logger.Error("发送邮件失败：", zap.Error(err))
+			} else {
+				logger.Info("下单失败，邮件已发送")
+			}
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package grabber
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/staparx/go_showstart/client"
+	"github.com/staparx/go_showstart/config"
+	"github.com/staparx/go_showstart/vars"
+)
+
+// CheckStatus 检查项的结果
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "pass"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+)
+
+// CheckItem 一项开售前检查
+type CheckItem struct {
+	Name   string
+	Status CheckStatus
+	Detail string
+}
+
+// Report 一个任务的开售前检查报告
+type Report struct {
+	Job   string
+	Time  time.Time
+	Items []CheckItem
+}
+
+func (r *Report) add(status CheckStatus, name, format string, args ...interface{}) {
+	r.Items = append(r.Items, CheckItem{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)})
+}
+
+// Failed 是否有未通过的检查项
+func (r Report) Failed() bool {
+	for _, item := range r.Items {
+		if item.Status == CheckFail {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "任务 %s 开售前检查（%s）：\n", r.Job, r.Time.Format("2006-01-02 15:04:05"))
+	for _, item := range r.Items {
+		mark := "✅"
+		switch item.Status {
+		case CheckWarn:
+			mark = "⚠️"
+		case CheckFail:
+			mark = "❌"
+		}
+		fmt.Fprintf(&b, "%s %s：%s\n", mark, item.Name, item.Detail)
+	}
+	if r.Failed() {
+		b.WriteString("结果：未通过\n")
+	} else {
+		b.WriteString("结果：通过\n")
+	}
+	return b.String()
+}
+
+// check 子命令的退出码
+const (
+	// CheckExitOK 所有检查项通过，允许有警告
+	CheckExitOK = 0
+	// CheckExitFailed 有未通过的检查项
+	CheckExitFailed = 1
+	// CheckExitError 参数或配置错误，未完成检查
+	CheckExitError = 2
+)
+
+// CheckJobs 依次检查 cfg 中的抢票任务，报告写入 w、错误写入 errw；jobName 不为空时只检查同名任务，返回 check 子命令的退出码
+func CheckJobs(ctx context.Context, cfg *config.Config, c client.ShowStartIface, jobName string, w, errw io.Writer) int {
+	jobs := cfg.Ticket.JobList()
+	if len(jobs) == 0 {
+		fmt.Fprintln(errw, "配置中没有抢票任务")
+		return CheckExitError
+	}
+
+	failed, checked := false, 0
+	for _, job := range jobs {
+		if jobName != "" && job.Name != jobName {
+			continue
+		}
+		checked++
+		report := NewEngine(cfg, job, c).Check(ctx)
+		fmt.Fprint(w, report.String())
+		if report.Failed() {
+			failed = true
+		}
+	}
+	if checked == 0 {
+		fmt.Fprintf(errw, "未找到抢票任务：%s\n", jobName)
+		return CheckExitError
+	}
+	if failed {
+		return CheckExitFailed
+	}
+	return CheckExitOK
+}
+
+// Check 执行开售前检查：登录凭证、活动与票档、购票数量、观演人与证件类型、收货地址与售卖渠道，不会下单。
+// 与 Run 相互独立，可在 Run 之前或运行期间调用
+func (e *Engine) Check(ctx context.Context) Report {
+	c := e.client
+	r := Report{Job: e.job.Name, Time: time.Now()}
+	if vars.TimeLocal != nil {
+		r.Time = r.Time.In(vars.TimeLocal)
+	}
+
+	if err := c.GetToken(ctx); err != nil {
+		r.add(CheckFail, "登录凭证", "获取 token 失败，请更新 token、cookie 等配置：%v", err)
+		return r
+	}
+	r.add(CheckPass, "登录凭证", "获取 token 成功")
+
+	detail, err := c.ActivityDetail(ctx, e.job.ActivityId)
+	if err != nil {
+		r.add(CheckFail, "活动", "查询活动 %d 失败：%v", e.job.ActivityId, err)
+		return r
+	}
+	r.add(CheckPass, "活动", "%d %s", e.job.ActivityId, detail.Result.ActivityName)
+	if detail.Result.SellTerminal != 0 {
+		r.add(CheckWarn, "售卖渠道", "sellTerminal=%d，该活动可能仅限 APP 等渠道购买", detail.Result.SellTerminal)
+	}
+	if detail.Result.IsPreAuth != 0 {
+		r.add(CheckWarn, "预授权", "isPreAuth=%d，该活动需要预先授权或实名认证：%s", detail.Result.IsPreAuth, detail.Result.PreAuthTips)
+	}
+	if detail.Result.DouyinStatus != 0 {
+		r.add(CheckWarn, "抖音渠道", "douyinStatus=%d，该活动可能通过抖音渠道售卖", detail.Result.DouyinStatus)
+	}
+
+	ticketList, err := c.ActivityTicketList(ctx, e.job.ActivityId)
+	if err != nil {
+		r.add(CheckFail, "票档", "查询票务信息失败：%v", err)
+		return r
+	}
+
+	num := len(e.job.People)
+	var addressChecked bool
+	for _, item := range e.job.List {
+		name := fmt.Sprintf("票档 %s - %s", item.Session, item.Price)
+		matched := matchTickets([]config.TicketList{item}, detail.Result.ActivityName, ticketList.Result)
+		if len(matched) == 0 {
+			var candidates []string
+			empty := false
+			for _, session := range ticketList.Result {
+				for _, ticketPrice := range session.TicketPriceList {
+					if len(ticketPrice.TicketList) == 0 {
+						empty = empty || (DelectStringBlank(session.SessionName) == DelectStringBlank(item.Session) && ticketPrice.Price == item.Price)
+						continue
+					}
+					candidates = append(candidates, fmt.Sprintf("%s / %s", session.SessionName, ticketPrice.Price))
+				}
+			}
+			if empty {
+				r.add(CheckFail, name, "服务端未返回该票价的票档信息，暂时无法下单")
+				continue
+			}
+			r.add(CheckFail, name, "未找到对应的场次与票价，可选：%s", strings.Join(candidates, "；"))
+			continue
+		}
+		ticket := matched[0]
+		info := ticket.Ticket
+
+		switch info.SaleStatus {
+		case vars.SaleStatusSoldOut, vars.SaleStatusActivityEnded, vars.SaleStatusSaleEnded:
+			r.add(CheckFail, name, "售卖状态：%s", info.SaleStatus)
+		default:
+			if info.SaleStatus.Known() {
+				r.add(CheckPass, name, "售卖状态：%s", info.SaleStatus)
+			} else {
+				r.add(CheckWarn, name, "售卖状态：%s", info.SaleStatus)
+			}
+		}
+
+		if start, err := e.resolveStartTime(ticket); err != nil {
+			r.add(CheckFail, name+" 开售时间", "%v", err)
+		} else {
+			r.add(CheckPass, name+" 开售时间", "%s", start.Format(startTimeLayout))
+		}
+
+		switch {
+		case info.LimitBuyNum > 0 && num > info.LimitBuyNum:
+			r.add(CheckFail, name+" 购票数量", "观演人 %d 人，超过每单限购 %d 张", num, info.LimitBuyNum)
+		case info.CanBuyNum > 0 && num > info.CanBuyNum:
+			r.add(CheckFail, name+" 购票数量", "观演人 %d 人，超过当前账号可购 %d 张", num, info.CanBuyNum)
+		default:
+			r.add(CheckPass, name+" 购票数量", "观演人 %d 人，限购 %d 张，可购 %d 张", num, info.LimitBuyNum, info.CanBuyNum)
+		}
+
+		confirm, err := c.Confirm(ctx, e.job.ActivityId, info.TicketID, strconv.Itoa(num))
+		if err != nil {
+			r.add(CheckFail, name+" 订单确认", "%v", err)
+			continue
+		}
+		orderInfo := confirm.Result.OrderInfoVo
+
+		needCp, err := orderInfo.BuyType.NeedAudience()
+		if err != nil {
+			r.add(CheckWarn, name+" 观演人", "未收录的购票方式 %s，按配置的观演人匹配", orderInfo.BuyType)
+			needCp = num > 0
+		}
+		if needCp {
+			e.checkAudience(ctx, &r, name, info.TicketID, ticket.CommonPerformerDocumentType)
+		} else {
+			r.add(CheckPass, name+" 观演人", "%s，无需选择观演人", orderInfo.BuyType)
+		}
+
+		needAddress, err := orderInfo.TicketPriceVo.TicketType.NeedAddress()
+		if err != nil {
+			r.add(CheckWarn, name+" 收货地址", "未收录的票类型 %s，无法判断是否需要地址", orderInfo.TicketPriceVo.TicketType)
+		}
+		if needAddress && !addressChecked {
+			addressChecked = true
+			e.checkAddress(ctx, &r)
+		}
+	}
+	return r
+}
+
+// checkAudience 检查配置的观演人是否都存在，且证件类型符合场次要求
+func (e *Engine) checkAudience(ctx context.Context, r *Report, name, ticketID, documentTypes string) {
+	cpResp, err := e.client.CpList(ctx, ticketID)
+	if err != nil {
+		r.add(CheckFail, name+" 观演人", "查询观演人失败：%v", err)
+		return
+	}
+
+	allowed := map[string]bool{}
+	for _, t := range strings.Split(documentTypes, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			allowed[t] = true
+		}
+	}
+
+	var problems []string
+	for _, user := range e.job.People {
+		found := false
+		for _, v := range cpResp.Result {
+			if v.Name != user {
+				continue
+			}
+			found = true
+			if len(allowed) > 0 && !allowed[strconv.Itoa(v.DocumentType)] {
+				problems = append(problems, fmt.Sprintf("%s 的证件类型 %s 不在场次支持的证件类型 %s 中", user, v.DocumentTypeStr, documentTypes))
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("未找到观演人 %s，请检查姓名是否与秀动中一致", user))
+		}
+	}
+	if len(problems) > 0 {
+		r.add(CheckFail, name+" 观演人", "%s", strings.Join(problems, "；"))
+		return
+	}
+	r.add(CheckPass, name+" 观演人", "%s 匹配成功", strings.Join(e.job.People, "、"))
+}
+
+// checkAddress 检查是否设置了默认收货地址
+func (e *Engine) checkAddress(ctx context.Context, r *Report) {
+	adressList, err := e.client.AdressList(ctx)
+	if err != nil {
+		r.add(CheckFail, "收货地址", "查询地址失败：%v", err)
+		return
+	}
+	for _, v := range adressList.Result {
+		if v.IsDefault == 1 {
+			r.add(CheckPass, "收货地址", "默认地址：%s", v.Address)
+			return
+		}
+	}
+	r.add(CheckFail, "收货地址", "快递票需要收货地址，请在秀动中设置默认地址")
+}
+
+// precheck 在开售前 ticket.precheck_minutes 配置的各时间点执行检查，结果交给 WithPrecheckHandler 设置的 handler
+func (e *Engine) precheck(ctx context.Context, start time.Time) {
+	if e.cfg.Ticket == nil || e.precheckHandler == nil {
+		return
+	}
+	for _, minutes := range e.cfg.Ticket.PrecheckMinutes {
+		at := start.Add(-time.Duration(minutes) * time.Minute)
+		if !time.Now().Before(at) {
+			continue
+		}
+		e.goBackground(func() {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(at)):
+				e.precheckHandler(e.Check(ctx))
+			}
+		})
+	}
+}
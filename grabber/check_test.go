@@ -0,0 +1,248 @@
+package grabber
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/staparx/go_showstart/client/showstarttest"
+	"github.com/staparx/go_showstart/config"
+)
+
+// runCheck 按 job 检查模拟服务中的活动
+func runCheck(t *testing.T, s *showstarttest.Server, job *config.TicketJob) Report {
+	t.Helper()
+	cfg := newTestConfig(t, s, job)
+	return NewEngine(cfg, cfg.Ticket.JobList()[0], newTestClient(cfg)).Check(context.Background())
+}
+
+// checkItem 名称为 name 的检查项
+func checkItem(t *testing.T, r Report, name string) CheckItem {
+	t.Helper()
+	for _, item := range r.Items {
+		if item.Name == name {
+			return item
+		}
+	}
+	t.Fatalf("报告中没有检查项 %s：\n%s", name, r)
+	return CheckItem{}
+}
+
+func expectItem(t *testing.T, r Report, name string, status CheckStatus, detail string) {
+	t.Helper()
+	item := checkItem(t, r, name)
+	if item.Status != status || !strings.Contains(item.Detail, detail) {
+		t.Errorf("%s = %s %q, want %s 包含 %q", name, item.Status, item.Detail, status, detail)
+	}
+}
+
+const checkTier = "票档 " + testSession + " - 388"
+
+func TestCheckPass(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+
+	r := runCheck(t, s, &config.TicketJob{List: []config.TicketList{{Session: testSession, Price: "388"}}})
+	if r.Failed() {
+		t.Fatalf("检查未通过：\n%s", r)
+	}
+	expectItem(t, r, "登录凭证", CheckPass, "成功")
+	expectItem(t, r, checkTier, CheckPass, "售卖状态")
+	expectItem(t, r, checkTier+" 购票数量", CheckPass, "观演人 1 人")
+	expectItem(t, r, checkTier+" 观演人", CheckPass, "观演人1 匹配成功")
+	if n := s.Count("/nj/order/order"); n != 0 {
+		t.Errorf("检查时下单 %d 次", n)
+	}
+}
+
+func TestCheckToken(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	s.Enqueue("/waf/gettoken", showstarttest.Reply{State: "0", Msg: "登录失效"})
+
+	r := runCheck(t, s, &config.TicketJob{List: []config.TicketList{{Session: testSession, Price: "388"}}})
+	expectItem(t, r, "登录凭证", CheckFail, "登录失效")
+	// 登录失败时不再检查其他项
+	if len(r.Items) != 1 || !r.Failed() {
+		t.Errorf("report:\n%s", r)
+	}
+}
+
+func TestCheckTierMatch(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+
+	r := runCheck(t, s, &config.TicketJob{List: []config.TicketList{
+		{Session: testSession, Price: "388"},
+		{Session: testSession, Price: "999"},
+		{Session: "2024-08-17 周六 20:00", Price: "388"},
+	}})
+	if !r.Failed() {
+		t.Fatalf("检查应未通过：\n%s", r)
+	}
+	expectItem(t, r, checkTier, CheckPass, "售卖状态")
+	expectItem(t, r, "票档 "+testSession+" - 999", CheckFail, testSession+" / 388；"+testSession+" / 588")
+	expectItem(t, r, "票档 2024-08-17 周六 20:00 - 388", CheckFail, "未找到对应的场次与票价")
+}
+
+func TestCheckEmptyTicketList(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	// 票价下没有票档信息
+	s.Enqueue("/wap/activity/V2/ticket/list", showstarttest.Reply{State: "1", Success: true, Result: []interface{}{
+		map[string]interface{}{
+			"sessionName": testSession,
+			"sessionId":   3249212,
+			"ticketPriceList": []interface{}{
+				map[string]interface{}{"price": "388", "ticketList": []interface{}{}},
+				map[string]interface{}{"price": "588"},
+			},
+		},
+	}})
+
+	r := runCheck(t, s, &config.TicketJob{List: []config.TicketList{{Session: testSession, Price: "388"}}})
+	expectItem(t, r, checkTier, CheckFail, "未返回该票价的票档信息")
+}
+
+func TestCheckBuyLimit(t *testing.T) {
+	fixture := showstarttest.DefaultFixture()
+	fixture.Sessions[0].Prices[0].CanBuyNum = 1
+	s := showstarttest.NewServer(fixture)
+	defer s.Close()
+
+	people := []string{"观演人1", "观演人2"}
+	r := runCheck(t, s, &config.TicketJob{People: people, List: []config.TicketList{
+		{Session: testSession, Price: "388"},
+		{Session: testSession, Price: "588"},
+	}})
+	expectItem(t, r, checkTier+" 购票数量", CheckFail, "超过当前账号可购 1 张")
+	expectItem(t, r, "票档 "+testSession+" - 588 购票数量", CheckPass, "观演人 2 人")
+
+	s2 := showstarttest.NewServer(nil)
+	defer s2.Close()
+	r = runCheck(t, s2, &config.TicketJob{People: []string{"1", "2", "3", "4", "5"}, List: []config.TicketList{{Session: testSession, Price: "388"}}})
+	expectItem(t, r, checkTier+" 购票数量", CheckFail, "超过每单限购 4 张")
+}
+
+func TestCheckAudience(t *testing.T) {
+	fixture := showstarttest.DefaultFixture()
+	// 场次不支持观演人1 的证件类型
+	fixture.DocumentType = "2,3"
+	s := showstarttest.NewServer(fixture)
+	defer s.Close()
+
+	r := runCheck(t, s, &config.TicketJob{People: []string{"观演人1", "不存在"}, List: []config.TicketList{{Session: testSession, Price: "388"}}})
+	item := checkItem(t, r, checkTier+" 观演人")
+	if item.Status != CheckFail || !strings.Contains(item.Detail, "观演人1 的证件类型") || !strings.Contains(item.Detail, "未找到观演人 不存在") {
+		t.Errorf("观演人 = %s %q", item.Status, item.Detail)
+	}
+
+	// 非实名制不检查观演人
+	fixture = showstarttest.DefaultFixture()
+	fixture.BuyType = 3
+	s2 := showstarttest.NewServer(fixture)
+	defer s2.Close()
+	r = runCheck(t, s2, &config.TicketJob{People: []string{"不存在"}, List: []config.TicketList{{Session: testSession, Price: "388"}}})
+	expectItem(t, r, checkTier+" 观演人", CheckPass, "无需选择观演人")
+	if s2.Count("/wap/cp/list") != 0 {
+		t.Error("非实名制不应查询观演人")
+	}
+}
+
+func TestCheckAddress(t *testing.T) {
+	fixture := showstarttest.DefaultFixture()
+	// 快递票需要收货地址
+	for _, ticket := range fixture.Sessions[0].Prices {
+		ticket.TicketType = 2
+	}
+	fixture.Addresses[0].IsDefault = false
+	s := showstarttest.NewServer(fixture)
+	defer s.Close()
+
+	r := runCheck(t, s, &config.TicketJob{List: []config.TicketList{
+		{Session: testSession, Price: "388"},
+		{Session: testSession, Price: "588"},
+	}})
+	expectItem(t, r, "收货地址", CheckFail, "请在秀动中设置默认地址")
+	// 多个票档只检查一次地址
+	if n := s.Count("/wap/address/list"); n != 1 {
+		t.Errorf("查询地址 %d 次, want 1", n)
+	}
+
+	fixture.Addresses[0].IsDefault = true
+	s2 := showstarttest.NewServer(fixture)
+	defer s2.Close()
+	r = runCheck(t, s2, &config.TicketJob{List: []config.TicketList{{Session: testSession, Price: "388"}}})
+	expectItem(t, r, "收货地址", CheckPass, "上海市测试路 1 号")
+
+	// 电子票不检查地址
+	s3 := showstarttest.NewServer(nil)
+	defer s3.Close()
+	runCheck(t, s3, &config.TicketJob{List: []config.TicketList{{Session: testSession, Price: "388"}}})
+	if s3.Count("/wap/address/list") != 0 {
+		t.Error("电子票不应查询地址")
+	}
+}
+
+func TestCheckSaleChannel(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	s.Enqueue("/wap/activity/details", showstarttest.Reply{State: "1", Success: true, Result: map[string]interface{}{
+		"activityId":   s.Fixture().ActivityID,
+		"activityName": s.Fixture().ActivityName,
+		"sellTerminal": 2,
+		"isPreAuth":    1,
+		"preAuthTips":  "需要实名认证",
+		"douyinStatus": 1,
+	}})
+
+	r := runCheck(t, s, &config.TicketJob{List: []config.TicketList{{Session: testSession, Price: "388"}}})
+	expectItem(t, r, "售卖渠道", CheckWarn, "sellTerminal=2")
+	expectItem(t, r, "预授权", CheckWarn, "需要实名认证")
+	expectItem(t, r, "抖音渠道", CheckWarn, "douyinStatus=1")
+	// 警告不影响检查结果
+	if r.Failed() {
+		t.Errorf("只有警告时应通过：\n%s", r)
+	}
+}
+
+func TestCheckJobsExitCode(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	cfg := newTestConfig(t, s,
+		&config.TicketJob{Name: "ok", List: []config.TicketList{{Session: testSession, Price: "388"}}},
+		&config.TicketJob{Name: "bad", List: []config.TicketList{{Session: testSession, Price: "999"}}},
+	)
+	c := newTestClient(cfg)
+
+	tests := []struct {
+		job     string
+		want    int
+		reports int
+		stderr  string
+	}{
+		{job: "ok", want: CheckExitOK, reports: 1},
+		{job: "bad", want: CheckExitFailed, reports: 1},
+		// 任一任务未通过即为 1
+		{job: "", want: CheckExitFailed, reports: 2},
+		{job: "missing", want: CheckExitError, stderr: "未找到抢票任务：missing"},
+	}
+	for _, tt := range tests {
+		var out, errOut bytes.Buffer
+		if got := CheckJobs(context.Background(), cfg, c, tt.job, &out, &errOut); got != tt.want {
+			t.Errorf("job %q: exit = %d, want %d\n%s%s", tt.job, got, tt.want, out.String(), errOut.String())
+		}
+		if n := strings.Count(out.String(), "开售前检查"); n != tt.reports {
+			t.Errorf("job %q: 输出 %d 份报告, want %d", tt.job, n, tt.reports)
+		}
+		if !strings.Contains(errOut.String(), tt.stderr) {
+			t.Errorf("job %q: stderr = %q", tt.job, errOut.String())
+		}
+	}
+
+	var out, errOut bytes.Buffer
+	if got := CheckJobs(context.Background(), &config.Config{Ticket: &config.Ticket{}}, c, "", &out, &errOut); got != CheckExitError {
+		t.Errorf("没有任务时 exit = %d, want %d", got, CheckExitError)
+	}
+}
@@ -0,0 +1,40 @@
+package grabber
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/staparx/go_showstart/config"
+	"go.uber.org/zap"
+)
+
+// dryRunMode 演练模式，未配置票务信息时为正常抢票
+func (e *Engine) dryRunMode() config.DryRun {
+	if e.cfg.Ticket == nil {
+		return config.DryRunOff
+	}
+	return e.cfg.Ticket.DryRun
+}
+
+// dryRun 演练模式：到抢票时间按抢购顺序输出各票档的下单请求，不调用下单接口
+func (e *Engine) dryRun(tiers []*tier) {
+	e.markFirstOrder(0, e.timeSource.Now())
+
+	placed := make([]PlacedOrder, 0, len(tiers))
+	for i, t := range tiers {
+		// 与 client.Order 一致，发送前补充 st_flpv 与 sign
+		req := *t.req
+		if e.cfg.Showstart != nil {
+			req.StFlpv = e.cfg.Showstart.StFlpv
+			req.Sign = e.cfg.Showstart.Sign
+		}
+		data, err := json.Marshal(&req)
+		if err != nil {
+			e.fail(fmt.Errorf("生成下单请求失败：%w", err))
+			return
+		}
+		e.logger.Info(fmt.Sprintf("🧪演练模式，第 %d 个票档的下单请求（未发送）：%s - %s", i+1, t.order.SessionName, t.order.Price), zap.String("orderReq", string(data)))
+		placed = append(placed, PlacedOrder{Order: t.order})
+	}
+	e.succeed(placed)
+}
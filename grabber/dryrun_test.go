@@ -0,0 +1,76 @@
+package grabber
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/staparx/go_showstart/client/showstarttest"
+	"github.com/staparx/go_showstart/config"
+)
+
+func newDryRunEngine(t *testing.T, s *showstarttest.Server, mode config.DryRun) (*Engine, *config.Config) {
+	t.Helper()
+	cfg := newTestConfig(t, s, &config.TicketJob{List: []config.TicketList{
+		{Session: testSession, Price: "388"},
+		{Session: testSession, Price: "588"},
+	}})
+	cfg.Ticket.DryRun = mode
+	return NewEngine(cfg, cfg.Ticket.JobList()[0], newTestClient(cfg)), cfg
+}
+
+func TestDryRunLogDoesNotOrder(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+
+	e, _ := newDryRunEngine(t, s, config.DryRunLog)
+	res, err := runEngine(t, e)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !res.DryRun || len(res.Orders) != 2 || res.OrderSn != "" {
+		t.Fatalf("result = %+v, want 2 个未下单的演练结果", res)
+	}
+	// 下单前的步骤照常执行
+	if s.Count("/order/wap/order/confirm") == 0 {
+		t.Error("演练模式应确认订单")
+	}
+	for _, path := range []string{"/nj/order/order", "/nj/order/coreOrder", "/nj/order/getOrderResult"} {
+		if n := s.Count(path); n != 0 {
+			t.Errorf("%s 请求 %d 次, want 0", path, n)
+		}
+	}
+}
+
+func TestDryRunSend(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+
+	e, _ := newDryRunEngine(t, s, config.DryRunSend)
+	res, err := runEngine(t, e)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !res.DryRun || res.OrderSn == "" || s.Count("/nj/order/order") == 0 {
+		t.Fatalf("result = %+v, 下单请求 %d 次, want 向模拟服务下单", res, s.Count("/nj/order/order"))
+	}
+}
+
+func TestDryRunSendRejectsShowstart(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+
+	for _, baseURL := range []string{"https://www.showstart.com", "https://pro1-api.showstart.com/", ""} {
+		// client 仍指向模拟服务，若未拦截，请求会出现在模拟服务中
+		e, cfg := newDryRunEngine(t, s, config.DryRunSend)
+		cfg.Showstart.BaseURL = baseURL
+		if _, err := runEngine(t, e); !errors.Is(err, ErrSetup) {
+			t.Errorf("base_url %q: err = %v, want ErrSetup", baseURL, err)
+		}
+		if err := cfg.CheckDryRun(); err == nil {
+			t.Errorf("base_url %q: CheckDryRun 应返回错误", baseURL)
+		}
+	}
+	if reqs := s.Requests(); len(reqs) != 0 {
+		t.Errorf("发出了 %d 个请求，第一个为 %s", len(reqs), reqs[0].Path)
+	}
+}
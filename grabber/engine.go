@@ -0,0 +1,330 @@
+// Package grabber 抢票流程：校验配置、确认订单、定时并发下单并查询订单结果
+package grabber
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/staparx/go_showstart/client"
+	"github.com/staparx/go_showstart/config"
+	"github.com/staparx/go_showstart/log"
+	"go.uber.org/zap"
+)
+
+// ErrSetup 抢票开始前的校验或订单确认失败，通常需要修改配置
+var ErrSetup = errors.New("抢票准备失败")
+
+// OrderDetail 待抢购的票档
+type OrderDetail struct {
+	ActivityName string
+	SessionName  string
+	Price        string
+	ActivityID   int
+	GoodType     int
+	TicketID     string
+	// StartTime 该票档的抢票时间
+	StartTime time.Time
+}
+
+// Result 抢票成功的结果，Order 与 OrderSn 为第一个成功的订单
+type Result struct {
+	Job     string
+	Order   *OrderDetail
+	OrderSn string
+	// Orders 所有成功的订单，parallel 策略下可能有多个
+	Orders []PlacedOrder
+	// DryRun 演练模式的结果，log 模式下 OrderSn 为空
+	DryRun bool
+}
+
+// PlacedOrder 一个下单成功的订单
+type PlacedOrder struct {
+	Order   *OrderDetail
+	OrderSn string
+}
+
+// EventType 抢票过程中的事件类型
+type EventType string
+
+const (
+	EventValidated EventType = "validated"
+	EventConfirmed EventType = "confirmed"
+	EventStarted   EventType = "started"
+	// EventLaunched 第一个下单请求已发出，Message 为与目标时间的差距
+	EventLaunched      EventType = "launched"
+	EventOrderFailed   EventType = "order_failed"
+	EventOrderAccepted EventType = "order_accepted"
+	// EventTierExhausted 票档售罄或达到限购，不再抢购该票档
+	EventTierExhausted EventType = "tier_exhausted"
+	EventSucceeded     EventType = "succeeded"
+	EventFailed        EventType = "failed"
+)
+
+// Event 抢票过程中的事件，Worker 为下单协程序号，与协程无关的事件为 -1
+type Event struct {
+	Time    time.Time
+	Job     string
+	Type    EventType
+	Worker  int
+	Order   *OrderDetail
+	Message string
+	Err     error
+}
+
+// eventBuffer 事件缓冲区大小，缓冲区满时丢弃新事件，避免阻塞抢票
+const eventBuffer = 256
+
+// Engine 一次抢票任务，状态都保存在实例中，可在同一进程中创建多个
+type Engine struct {
+	cfg    *config.Config
+	job    *config.TicketJob
+	client client.ShowStartIface
+	logger *zap.Logger
+
+	events chan Event
+	result chan Result
+	errs   chan error
+
+	// wg 后台协程，Run 返回前等待其退出
+	wg sync.WaitGroup
+
+	// timeSource 开售计时使用的时间来源，默认为本机时间
+	timeSource client.Clock
+
+	// clock 开售前对时得到的服务端时钟偏差
+	clockMu sync.Mutex
+	clock   client.ClockOffset
+
+	// launchAt 第一个下单请求的目标时间（本机时间），firstOrderOnce 记录实际发出时间
+	launchAt       time.Time
+	firstOrderOnce sync.Once
+
+	// input 手动匹配票档时读取终端输入
+	input *bufio.Reader
+
+	// precheckHandler 开售前定时检查的结果处理
+	precheckHandler func(Report)
+
+	// gate 持有 orderJobKey 与已成功的订单数达到上限时，其余协程暂停下单
+	gate *orderGate
+}
+
+// Option Engine 的可选配置
+type Option func(*Engine)
+
+// WithLogger 使用自定义的日志
+func WithLogger(logger *zap.Logger) Option {
+	return func(e *Engine) {
+		if logger != nil {
+			e.logger = logger
+		}
+	}
+}
+
+// WithClock 预热、等待开售与记录第一个下单请求时使用的时间来源，默认为本机时间
+func WithClock(clock client.Clock) Option {
+	return func(e *Engine) {
+		if clock != nil {
+			e.timeSource = clock
+		}
+	}
+}
+
+// WithPrecheckHandler 按 ticket.precheck_minutes 在开售前定时检查，handler 接收检查报告
+func WithPrecheckHandler(handler func(Report)) Option {
+	return func(e *Engine) {
+		e.precheckHandler = handler
+	}
+}
+
+// NewEngine 根据配置、抢票任务与 client 创建抢票任务，多个任务可共用一个 client
+func NewEngine(cfg *config.Config, job *config.TicketJob, c client.ShowStartIface, opts ...Option) *Engine {
+	logger := log.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	e := &Engine{
+		cfg:        cfg,
+		job:        job,
+		client:     c,
+		logger:     logger,
+		timeSource: client.SystemClock,
+		events:     make(chan Event, eventBuffer),
+		result:     make(chan Result, 1),
+		errs:       make(chan error, 1),
+		gate:       newOrderGate(job.MaxOrders),
+		input:      bufio.NewReader(os.Stdin),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	// 多个任务同时运行时，日志中带上任务名称
+	e.logger = e.logger.With(zap.String("job", job.Name))
+	return e
+}
+
+// Job 任务名称
+func (e *Engine) Job() string {
+	return e.job.Name
+}
+
+// Events 抢票过程中的事件，Run 返回后关闭
+func (e *Engine) Events() <-chan Event {
+	return e.events
+}
+
+// Run 执行抢票，直到抢票成功、出现无法恢复的错误或 ctx 结束；每个 Engine 只能调用一次
+func (e *Engine) Run(ctx context.Context) (Result, error) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer func() {
+		// 停止并等待所有协程退出后再关闭事件
+		cancel()
+		e.wg.Wait()
+		close(e.events)
+	}()
+
+	// 配置未经 Validate 时同样不能向秀动下单
+	if err := e.cfg.CheckDryRun(); err != nil {
+		e.emit(Event{Type: EventFailed, Worker: -1, Err: err})
+		return Result{}, fmt.Errorf("%w: %w", ErrSetup, err)
+	}
+	if mode := e.dryRunMode(); mode != config.DryRunOff {
+		e.logger.Warn(fmt.Sprintf("🧪演练模式（%s），不会向秀动下单", mode))
+	}
+
+	buyTicketList, err := e.validate(runCtx)
+	if err != nil {
+		e.emit(Event{Type: EventFailed, Worker: -1, Err: err})
+		return Result{}, fmt.Errorf("%w: %w", ErrSetup, err)
+	}
+	e.emit(Event{Type: EventValidated, Worker: -1, Message: fmt.Sprintf("匹配到 %d 个票档", len(buyTicketList))})
+
+	var tiers []*tier
+	for _, ticket := range buyTicketList {
+		order := &OrderDetail{
+			ActivityName: ticket.ActivityName,
+			SessionName:  ticket.SessionName,
+			Price:        ticket.Ticket.SellingPrice,
+			ActivityID:   e.job.ActivityId,
+			GoodType:     ticket.Ticket.GoodType,
+			TicketID:     ticket.Ticket.TicketID,
+			StartTime:    ticket.StartTime,
+		}
+		t, err := e.confirmOrder(runCtx, order)
+		if err != nil {
+			e.emit(Event{Type: EventFailed, Worker: -1, Order: order, Err: err})
+			return Result{}, fmt.Errorf("%w: %w", ErrSetup, err)
+		}
+		e.emit(Event{Type: EventConfirmed, Worker: -1, Order: order})
+		tiers = append(tiers, t)
+	}
+
+	e.schedule(runCtx, tiers)
+
+	select {
+	case res := <-e.result:
+		for _, placed := range res.Orders {
+			e.emit(Event{Type: EventSucceeded, Worker: -1, Order: placed.Order, Message: placed.OrderSn})
+		}
+		return res, nil
+	case err := <-e.errs:
+		e.emit(Event{Type: EventFailed, Worker: -1, Err: err})
+		return Result{}, err
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+}
+
+// goBackground 启动由 Run 等待退出的协程
+func (e *Engine) goBackground(fn func()) {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		fn()
+	}()
+}
+
+func (e *Engine) emit(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = e.timeSource.Now()
+	}
+	ev.Job = e.job.Name
+	select {
+	case e.events <- ev:
+	default:
+	}
+}
+
+// succeed 记录抢票成功，只有第一次生效
+func (e *Engine) succeed(orders []PlacedOrder) {
+	if len(orders) == 0 {
+		return
+	}
+	res := Result{Job: e.job.Name, Order: orders[0].Order, OrderSn: orders[0].OrderSn, Orders: orders, DryRun: e.dryRunMode() != config.DryRunOff}
+	select {
+	case e.result <- res:
+	default:
+	}
+}
+
+// fail 记录无法恢复的错误，只有第一次生效
+func (e *Engine) fail(err error) {
+	select {
+	case e.errs <- err:
+	default:
+	}
+}
+
+// orderGate 限制持有 orderJobKey 与已成功的订单总数不超过 max，避免重复下单
+type orderGate struct {
+	mu        sync.Mutex
+	max       int
+	holding   int
+	succeeded int
+}
+
+func newOrderGate(max int) *orderGate {
+	if max <= 0 {
+		max = 1
+	}
+	return &orderGate{max: max}
+}
+
+// full 名额已用完，暂停下单
+func (g *orderGate) full() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.holding+g.succeeded >= g.max
+}
+
+// acquire 拿到 orderJobKey 时占用名额，名额已满时返回 false
+func (g *orderGate) acquire() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.holding+g.succeeded >= g.max {
+		return false
+	}
+	g.holding++
+	return true
+}
+
+// release 订单失败，归还名额
+func (g *orderGate) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.holding--
+}
+
+// done 订单成功，返回已成功的订单数
+func (g *orderGate) done() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.holding--
+	g.succeeded++
+	return g.succeeded
+}
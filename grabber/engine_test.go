@@ -0,0 +1,140 @@
+package grabber
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/staparx/go_showstart/client"
+	"github.com/staparx/go_showstart/client/showstarttest"
+	"github.com/staparx/go_showstart/config"
+	"github.com/staparx/go_showstart/vars"
+)
+
+const testSession = "2024-08-16 周五 20:00"
+
+// newTestEngine 创建指向模拟服务的抢票任务，开售时间为 200ms 后；关闭对时与预热以缩短测试时间
+func newTestEngine(t *testing.T, s *showstarttest.Server, job *config.TicketJob, opts ...client.Option) *Engine {
+	t.Helper()
+	cfg := newTestConfig(t, s, job)
+	return NewEngine(cfg, cfg.Ticket.JobList()[0], newTestClient(cfg, opts...))
+}
+
+// newTestConfig 创建指向模拟服务的配置，未填写的任务字段使用模拟服务的默认值
+func newTestConfig(t *testing.T, s *showstarttest.Server, jobs ...*config.TicketJob) *config.Config {
+	t.Helper()
+	if vars.TimeLocal == nil {
+		vars.TimeLocal = time.Local
+	}
+	for _, job := range jobs {
+		if job.Name == "" {
+			job.Name = t.Name()
+		}
+		if job.ActivityId == 0 {
+			job.ActivityId = s.Fixture().ActivityID
+		}
+		if job.StartTime == "" {
+			job.StartTime = time.Now().Add(200 * time.Millisecond).Format(startTimeLayout)
+		}
+		if len(job.People) == 0 {
+			job.People = []string{"观演人1"}
+		}
+	}
+	return &config.Config{
+		Showstart: &config.Showstart{
+			Sign:      "sig",
+			Token:     "abcdefghijklmnopqrstuvwxyz0123456789",
+			Cterminal: "wap",
+			Cversion:  "997",
+			Cusid:     "1",
+			Cusname:   "nil",
+			BaseURL:   s.BaseURL,
+		},
+		System: &config.System{
+			MaxGoroutine:     3,
+			MinInterval:      10,
+			MaxInterval:      20,
+			ClockSyncSamples: -1,
+			PrewarmSeconds:   -1,
+		},
+		Ticket: &config.Ticket{Jobs: jobs},
+	}
+}
+
+// newTestClient 创建不限流、快速轮询订单结果的 client
+func newTestClient(cfg *config.Config, opts ...client.Option) client.ShowStartIface {
+	poll := client.RetryPolicy{
+		MaxAttempts: 50,
+		BaseDelay:   5 * time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+		Multiplier:  1,
+		Retryable:   client.DefaultPollPolicy.Retryable,
+	}
+	opts = append([]client.Option{client.WithRateLimiter(nil), client.WithPollPolicy(poll)}, opts...)
+	return client.NewShowStartClient(cfg.Showstart, opts...)
+}
+
+func runEngine(t *testing.T, e *Engine) (Result, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return e.Run(ctx)
+}
+
+func TestRunPlacesOrder(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	s.SetPending(2)
+
+	e := newTestEngine(t, s, &config.TicketJob{List: []config.TicketList{{Session: testSession, Price: "388"}}})
+	res, err := runEngine(t, e)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(res.Orders) != 1 || res.Orders[0].Order.Price != "388" {
+		t.Fatalf("orders = %+v, want 1 个 388 的订单", res.Orders)
+	}
+	if orders := s.Orders(); len(orders) != 1 || orders[0] != res.OrderSn {
+		t.Errorf("服务端订单 %v, 结果 %s", orders, res.OrderSn)
+	}
+	if n := s.SignFailures(); n != 0 {
+		t.Errorf("crpsign 校验失败 %d 次", n)
+	}
+}
+
+func TestRunCoreOrder(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	s.SetCoreOrder(true)
+	s.SetPending(1)
+
+	e := newTestEngine(t, s, &config.TicketJob{List: []config.TicketList{{Session: testSession, Price: "388"}}})
+	res, err := runEngine(t, e)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.OrderSn == "" || s.Count("/nj/order/coreOrder") == 0 {
+		t.Fatalf("orderSn = %q, coreOrder 请求 %d 次", res.OrderSn, s.Count("/nj/order/coreOrder"))
+	}
+}
+
+func TestRunTokenExpiredBeforeOrder(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+
+	e := newTestEngine(t, s, &config.TicketJob{List: []config.TicketList{{Session: testSession, Price: "388"}}})
+	// 确认订单后 token 失效，下单时需刷新 token
+	go func() {
+		for ev := range e.Events() {
+			if ev.Type == EventConfirmed {
+				s.ExpireToken()
+			}
+		}
+	}()
+	if _, err := runEngine(t, e); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if n := s.Count("/waf/gettoken"); n < 2 {
+		t.Errorf("gettoken 请求 %d 次, want 至少 2", n)
+	}
+}
@@ -0,0 +1,34 @@
+package grabber
+
+import (
+	"context"
+	"sync"
+
+	"github.com/staparx/go_showstart/client"
+	"github.com/staparx/go_showstart/config"
+)
+
+// RunJobs 为每个抢票任务创建 Engine 并同时运行，全部结束后返回成功的任务数
+// 任务之间互不影响：某个任务准备失败或抢票失败不会取消其他任务；done 在每个任务结束时调用，可能被并发调用
+func RunJobs(ctx context.Context, cfg *config.Config, c client.ShowStartIface, done func(job *config.TicketJob, result Result, err error), opts ...Option) int {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for _, job := range cfg.Ticket.JobList() {
+		wg.Add(1)
+		go func(job *config.TicketJob) {
+			defer wg.Done()
+			result, err := NewEngine(cfg, job, c, opts...).Run(ctx)
+			if done != nil {
+				done(job, result, err)
+			}
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}(job)
+	}
+	wg.Wait()
+	return succeeded
+}
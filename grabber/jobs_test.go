@@ -0,0 +1,52 @@
+package grabber
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/staparx/go_showstart/client/showstarttest"
+	"github.com/staparx/go_showstart/config"
+)
+
+func TestRunJobsIndependent(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+
+	// 场次不存在的任务在开售前就准备失败，不应影响其他任务
+	cfg := newTestConfig(t, s,
+		&config.TicketJob{Name: "388", List: []config.TicketList{{Session: testSession, Price: "388"}}},
+		&config.TicketJob{Name: "588", List: []config.TicketList{{Session: testSession, Price: "588"}}},
+		&config.TicketJob{Name: "bad", List: []config.TicketList{{Session: "不存在的场次", Price: "388"}}},
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	results := map[string]Result{}
+	errs := map[string]error{}
+	succeeded := RunJobs(ctx, cfg, newTestClient(cfg), func(job *config.TicketJob, result Result, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[job.Name] = result
+		errs[job.Name] = err
+	})
+
+	if succeeded != 2 {
+		t.Errorf("成功 %d 个任务, want 2", succeeded)
+	}
+	if err := errs["bad"]; !errors.Is(err, ErrSetup) {
+		t.Errorf("bad err = %v, want ErrSetup", err)
+	}
+	for _, name := range []string{"388", "588"} {
+		res := results[name]
+		if errs[name] != nil || len(res.Orders) != 1 || res.Orders[0].Order.Price != name {
+			t.Errorf("%s: orders = %+v, err = %v", name, res.Orders, errs[name])
+		}
+	}
+	if orders := s.Orders(); len(orders) != 2 {
+		t.Errorf("服务端订单 %v, want 2 个", orders)
+	}
+}
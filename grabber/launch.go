@@ -0,0 +1,112 @@
+package grabber
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/staparx/go_showstart/client"
+	"go.uber.org/zap"
+)
+
+// defaultPrewarm 默认在开售前多久开始预热连接
+const defaultPrewarm = 5 * time.Second
+
+// prewarmInterval 预热请求的间隔，保持连接不被服务端关闭
+const prewarmInterval = time.Second
+
+// prewarmQuiet 开售前停止预热的时间，避免预热请求与第一批下单请求争用连接
+const prewarmQuiet = 200 * time.Millisecond
+
+// spinWindow 精确计时最后阶段忙等待的时长，弥补定时器的调度误差
+const spinWindow = 2 * time.Millisecond
+
+// launchTime 服务端时间 server 对应的本机发出第一个下单请求的时间，已扣除 lead_ms
+func (e *Engine) launchTime(server time.Time) time.Time {
+	return e.localTime(server).Add(-time.Duration(e.cfg.System.LeadMs) * time.Millisecond)
+}
+
+// prewarm 在 target 前的几秒内按下单协程数保持空闲连接，target 前 prewarmQuiet 停止
+func (e *Engine) prewarm(ctx context.Context, target time.Time) {
+	lead := defaultPrewarm
+	if s := e.cfg.System.PrewarmSeconds; s < 0 {
+		return
+	} else if s > 0 {
+		lead = time.Duration(s) * time.Second
+	}
+	conns := e.cfg.System.MaxGoroutine
+	if conns <= 0 {
+		return
+	}
+
+	// target 前 prewarmQuiet 停止预热
+	warmCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		if e.sleepUntil(warmCtx, target.Add(-prewarmQuiet)) == nil {
+			cancel()
+		}
+	}()
+	if err := e.sleepUntil(warmCtx, target.Add(-lead)); err != nil {
+		return
+	}
+
+	logged := false
+	for warmCtx.Err() == nil {
+		n, err := e.client.Warmup(warmCtx, conns)
+		switch {
+		case err != nil && warmCtx.Err() == nil:
+			e.logger.Warn("⚠️ 预热连接失败", zap.Error(err))
+		case err == nil && !logged:
+			e.logger.Info(fmt.Sprintf("🔥已预热 %d 个连接", n))
+			logged = true
+		}
+		select {
+		case <-warmCtx.Done():
+		case <-e.timeSource.After(prewarmInterval):
+		}
+	}
+}
+
+// sleepUntil 按 Engine 的时间来源精确等待到 t
+func (e *Engine) sleepUntil(ctx context.Context, t time.Time) error {
+	return preciseSleepUntil(ctx, e.timeSource, t)
+}
+
+// preciseSleepUntil 等待到 t：先用定时器等到 t 前 spinWindow，再忙等待到 t；
+// 自定义的时间来源按自身的时间准时返回，不需要忙等待
+func preciseSleepUntil(ctx context.Context, clock client.Clock, t time.Time) error {
+	spin := spinWindow
+	if clock != client.SystemClock {
+		spin = 0
+	}
+	if d := t.Sub(clock.Now()) - spin; d > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-clock.After(d):
+		}
+	}
+	for clock.Now().Before(t) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		runtime.Gosched()
+	}
+	return ctx.Err()
+}
+
+// markFirstOrder 记录第一个下单请求的发出时间，与目标时间对比
+func (e *Engine) markFirstOrder(index int, at time.Time) {
+	e.firstOrderOnce.Do(func() {
+		delay := at.Sub(e.launchAt)
+		e.logger.Info("🎯第一个下单请求已发出",
+			zap.Int("worker", index),
+			zap.String("target", e.launchAt.Format(startTimeLayout)),
+			zap.String("actual", at.In(e.launchAt.Location()).Format(startTimeLayout)),
+			zap.Duration("delay", delay),
+		)
+		e.emit(Event{Time: at, Type: EventLaunched, Worker: index, Message: fmt.Sprintf("比目标时间晚 %s", delay)})
+	})
+}
@@ -0,0 +1,201 @@
+package grabber
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/staparx/go_showstart/client"
+	"github.com/staparx/go_showstart/client/showstarttest"
+	"github.com/staparx/go_showstart/config"
+)
+
+// manualClock 手动推进的时间来源，After 在时间推进到期限时返回
+type manualClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []clockWaiter
+}
+
+type clockWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newManualClock(now time.Time) *manualClock {
+	return &manualClock{now: now}
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, clockWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// AdvanceTo 推进到 t，到期的 After 返回
+func (c *manualClock) AdvanceTo(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(t) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- t
+	}
+	c.waiters = pending
+}
+
+// next 最早的等待期限
+func (c *manualClock) next() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.waiters) == 0 {
+		return time.Time{}, false
+	}
+	at := make([]time.Time, 0, len(c.waiters))
+	for _, w := range c.waiters {
+		at = append(at, w.at)
+	}
+	sort.Slice(at, func(i, j int) bool { return at[i].Before(at[j]) })
+	return at[0], true
+}
+
+// stepTo 每次推进到最早的等待期限，直到 until；推进前稍等各协程开始等待
+func (c *manualClock) stepTo(t *testing.T, until time.Time) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Now().Before(until) {
+		if time.Now().After(deadline) {
+			t.Fatalf("时间停在 %v，没有协程等待", c.Now())
+		}
+		time.Sleep(20 * time.Millisecond)
+		at, ok := c.next()
+		if !ok {
+			continue
+		}
+		if at.After(until) {
+			at = until
+		}
+		c.AdvanceTo(at)
+	}
+}
+
+// warmupCounter 记录预热次数
+type warmupCounter struct {
+	client.ShowStartIface
+	calls atomic.Int32
+}
+
+func (w *warmupCounter) Warmup(ctx context.Context, conns int) (int, error) {
+	w.calls.Add(1)
+	return w.ShowStartIface.Warmup(ctx, conns)
+}
+
+func TestFirstOrderFiresLeadBeforeStart(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+
+	start := time.Date(2024, 8, 16, 20, 0, 0, 0, time.Local)
+	cfg := newTestConfig(t, s, &config.TicketJob{
+		StartTime: start.Format(startTimeLayout),
+		List:      []config.TicketList{{Session: testSession, Price: "388"}},
+	})
+	cfg.System.LeadMs = 50
+	cfg.System.PrewarmSeconds = 1
+	clock := newManualClock(start.Add(-3 * time.Second))
+	c := &warmupCounter{ShowStartIface: newTestClient(cfg)}
+	e := NewEngine(cfg, cfg.Ticket.JobList()[0], c, WithClock(clock))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := runEngine(t, e)
+		done <- err
+	}()
+	target := start.Add(-50 * time.Millisecond)
+	clock.stepTo(t, target)
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	var launched *Event
+	for ev := range e.Events() {
+		if ev.Type == EventLaunched {
+			ev := ev
+			launched = &ev
+		}
+	}
+	if launched == nil || !launched.Time.Equal(target) {
+		t.Fatalf("第一个下单请求 %+v, want 发出于 %v", launched, target)
+	}
+	if c.calls.Load() == 0 {
+		t.Error("开售前没有预热连接")
+	}
+}
+
+func TestPrewarmStops(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+
+	target := time.Date(2024, 8, 16, 20, 0, 0, 0, time.Local)
+	newPrewarm := func(ctx context.Context) (*manualClock, *warmupCounter, chan struct{}) {
+		cfg := newTestConfig(t, s, &config.TicketJob{})
+		cfg.System.PrewarmSeconds = 5
+		clock := newManualClock(target.Add(-10 * time.Second))
+		c := &warmupCounter{ShowStartIface: newTestClient(cfg)}
+		e := NewEngine(cfg, cfg.Ticket.JobList()[0], c, WithClock(clock))
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			e.prewarm(ctx, target)
+		}()
+		return clock, c, done
+	}
+	waitDone := func(t *testing.T, done chan struct{}) {
+		t.Helper()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("预热没有停止")
+		}
+	}
+
+	t.Run("ctx 取消", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		clock, c, done := newPrewarm(ctx)
+		clock.stepTo(t, target.Add(-3*time.Second))
+		if c.calls.Load() == 0 {
+			t.Fatal("开售前 5s 应开始预热")
+		}
+		// 时间不再推进，取消后立即停止
+		cancel()
+		waitDone(t, done)
+	})
+
+	t.Run("开售前停止", func(t *testing.T) {
+		clock, c, done := newPrewarm(context.Background())
+		clock.stepTo(t, target.Add(-prewarmQuiet))
+		waitDone(t, done)
+		if c.calls.Load() == 0 {
+			t.Error("开售前 5s 应开始预热")
+		}
+	})
+}
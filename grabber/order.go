@@ -0,0 +1,403 @@
+package grabber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/staparx/go_showstart/client"
+	"github.com/staparx/go_showstart/config"
+	"go.uber.org/zap"
+)
+
+// confirmOrder 确认订单信息、匹配观演人与地址，生成票档的下单请求
+func (e *Engine) confirmOrder(ctx context.Context, order *OrderDetail) (*tier, error) {
+	c := e.client
+	num := len(e.job.People)
+	//订单信息确认
+	confirm, err := c.Confirm(ctx, order.ActivityID, order.TicketID, fmt.Sprintf("%d", num))
+	if err != nil {
+		e.logger.Error("❌ 订单信息确认失败：", zap.Error(err))
+		return nil, err
+	}
+
+	e.logger.Info("👀订单信息确认成功！", zap.Any("ticket_id", order.TicketID))
+
+	pay := strconv.FormatFloat(confirm.Result.OrderInfoVo.TicketPriceVo.Price*float64(num), 'f', 2, 64)
+	//下单
+	orderReq := &client.OrderReq{
+		OrderDetails: []*client.OrderDetail{
+			{
+				GoodsType:  order.GoodType,
+				SkuType:    int(confirm.Result.OrderInfoVo.TicketPriceVo.TicketType),
+				Num:        fmt.Sprintf("%d", num),
+				GoodsID:    confirm.Result.OrderInfoVo.ActivityID,
+				SkuID:      confirm.Result.OrderInfoVo.TicketPriceVo.TicketID,
+				Price:      confirm.Result.OrderInfoVo.TicketPriceVo.Price,
+				GoodsPhoto: confirm.Result.OrderInfoVo.Poster,
+				DyPOIType:  confirm.Result.OrderInfoVo.TicketPriceVo.DyPOIType,
+				GoodsName:  confirm.Result.OrderInfoVo.Title,
+			},
+		},
+		CommonPerfomerIds: []int{},
+		AreaCode:          confirm.Result.OrderInfoVo.AreaCode,
+		Telephone:         confirm.Result.OrderInfoVo.Telephone,
+		AddressID:         "",
+		TeamID:            "",
+		CouponID:          "",
+		CheckCode:         "",
+		Source:            0,
+		Discount:          0,
+		SessionID:         confirm.Result.OrderInfoVo.SessionID,
+		Freight:           0,
+		AmountPayable:     pay,
+		TotalAmount:       pay,
+		Partner:           "",
+		OrderSource:       1,
+		VideoID:           "",
+		PayVideotype:      "",
+		StFlpv:            "",
+		Sign:              "",
+		TrackPath:         "",
+	}
+	//是否需要查询观演人
+	buyType := confirm.Result.OrderInfoVo.BuyType
+	needCp, err := buyType.NeedAudience()
+	if err != nil {
+		// 未收录的购票方式：配置了观演人时按需要观演人处理
+		needCp = len(e.job.People) > 0
+		e.logger.Warn("⚠️ 未收录的购票方式，请反馈该活动以便补充", zap.Stringer("buyType", buyType), zap.Bool("matchAudience", needCp), zap.Error(err))
+	}
+	if needCp {
+		e.logger.Info(fmt.Sprintf("🏃票务类型为:%s ，匹配观演人信息中...", buyType))
+		//查询观演人信息
+		cpResp, err := c.CpList(ctx, order.TicketID)
+		if err != nil {
+			e.logger.Error("❌ 查询观演人信息失败：", zap.Error(err))
+			return nil, err
+		}
+
+		var perfomerIds []int
+		for _, v := range cpResp.Result {
+			for _, user := range e.job.People {
+				if v.Name == user {
+					perfomerIds = append(perfomerIds, v.ID)
+				}
+			}
+		}
+
+		if len(perfomerIds) > 0 && len(perfomerIds) == len(e.job.People) {
+			e.logger.Info("🙎观演人信息匹配成功！!")
+			orderReq.CommonPerfomerIds = perfomerIds
+		} else {
+			e.logger.Error("❌ 观演人信息匹配失败")
+			return nil, errors.New("观演人信息匹配失败")
+		}
+	} else {
+		e.logger.Info(fmt.Sprintf("🏃票务类型为:%s ，无需选择观演人 ", buyType))
+	}
+
+	e.logger.Info(fmt.Sprintf("👪观演人数：%d（请注意活动的购票数量限制！）", num))
+
+	//是否需要填写地址
+	ticketType := confirm.Result.OrderInfoVo.TicketPriceVo.TicketType
+	needAddress, err := ticketType.NeedAddress()
+	if err != nil {
+		// 未收录的票类型无法判断是否需要地址，继续下单可能因缺少地址失败
+		e.logger.Warn("⚠️ 未收录的票类型，按无需地址处理，请反馈该活动以便补充", zap.Stringer("ticketType", ticketType), zap.Error(err))
+	}
+	if needAddress {
+		e.logger.Info(fmt.Sprintf("🏃地址票务类型为:%s ，匹配地址信息中...", ticketType))
+		//查询地址信息
+		adressList, err := c.AdressList(ctx)
+		if err != nil {
+			e.logger.Error("❌ 查询地址信息失败：", zap.Error(err))
+			return nil, err
+		}
+
+		if len(adressList.Result) > 0 {
+			for _, v := range adressList.Result {
+				if v.IsDefault == 1 {
+					orderReq.AddressID = strconv.Itoa(v.ID)
+					e.logger.Info(fmt.Sprintf("🏠地址信息匹配成功！地址：%s", v.Address))
+					break
+				}
+			}
+			if orderReq.AddressID == "" {
+				e.logger.Error("❌ 地址信息匹配失败，请设置默认地址")
+				return nil, errors.New("地址信息匹配失败，请设置默认地址")
+			}
+		} else {
+			e.logger.Error("❌ 地址信息匹配失败，请设置默认地址")
+			return nil, errors.New("地址信息匹配失败，请设置默认地址")
+		}
+	} else {
+		e.logger.Info(fmt.Sprintf("🏃地址票务类型为:%s ，无需选择地址 ", ticketType))
+	}
+
+	price := confirm.Result.OrderInfoVo.TicketPriceVo.Price
+	return &tier{order: order, req: orderReq, price: price}, nil
+}
+
+// schedule 倒计时、开售前刷新 token，并在开售时间按策略启动下单协程
+func (e *Engine) schedule(ctx context.Context, tiers []*tier) {
+	c := e.client
+
+	// 各票档开售时间不同时，从最早的开售时间开始抢购，未开售的票档在抢购前等待
+	t := tiers[0].order.StartTime
+	for _, tr := range tiers[1:] {
+		if tr.order.StartTime.Before(t) {
+			t = tr.order.StartTime
+		}
+	}
+
+	e.logger.Info(fmt.Sprintf("🕒 抢票启动时间为：%s", t.Format(startTimeLayout)))
+	e.precheck(ctx, t)
+
+	// time.Millisecond，精确到毫秒
+	startTime := t.UnixNano() / int64(time.Millisecond)
+
+	// 开始抢票进程
+	StartOrder := func() {
+		// 开售前对时，之后按服务端时间启动
+		e.syncClock(ctx, t)
+		target := e.launchTime(t)
+		// 开售前预热连接，精确计时到目标时间
+		e.goBackground(func() { e.prewarm(ctx, target) })
+		if err := e.sleepUntil(ctx, target); err != nil {
+			return
+		}
+		e.launchAt = target
+		e.logger.Info("🚀活动即将开始，开始监听抢票！！！")
+		e.emit(Event{Type: EventStarted, Worker: -1})
+		e.runStrategy(ctx, tiers)
+	}
+
+	// 倒计时进程
+	Countdown := func() {
+		// since 精确到毫秒
+		since := (startTime - time.Now().UnixNano()/int64(time.Millisecond))
+		// since 减去 10s
+		since -= 10000
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(since) * time.Millisecond):
+			since = (e.localTime(t).UnixNano()/int64(time.Millisecond) - time.Now().UnixNano()/int64(time.Millisecond))
+			// 加入 ctx.Done() 退出
+			for since > 0 && ctx.Err() == nil {
+				e.logger.Info(fmt.Sprintf("🕒 距离抢票开始还有：%d秒", since/1000))
+				time.Sleep(1 * time.Second)
+				since -= 1000
+			}
+		}
+	}
+
+	// token 重新获取进程
+	GetTokenAgain := func() {
+		// since 精确到毫秒
+		since := (startTime - time.Now().UnixNano()/int64(time.Millisecond))
+		// since 减去 3min
+		since -= 1000 * 60 * 3
+		// 如果距离开始时间小于3min，不再重新获取token
+		if since < 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Duration(since) * time.Millisecond):
+			// token 重新获取
+			err := c.GetToken(ctx)
+			if err != nil {
+				e.logger.Error("token重新获取失败：", zap.Error(err))
+				// 再次获取
+				err = c.GetToken(ctx)
+				if err != nil {
+					e.logger.Error("token重新获取失败：", zap.Error(err))
+					e.fail(fmt.Errorf("token重新获取失败：%w", err))
+					return
+				}
+			}
+		}
+	}
+
+	// 启动
+	e.goBackground(StartOrder)
+	e.goBackground(Countdown)
+	e.goBackground(GetTokenAgain)
+}
+
+// goOrder 下单协程，拿到 orderJobKey 后查询订单结果，失败时继续下单，票档售罄、达到限购或已下单成功时退出
+func (e *Engine) goOrder(ctx context.Context, index int, run *tierRun) {
+	c, cfg := e.client, e.cfg
+	pacer, orderReq, order := run.pacer, run.tier.req, run.tier.order
+	logPrefix := fmt.Sprintf("[%d]", index)
+
+	// 除线程0，初始循环仍然加入随机等待
+	firstLoop := index == 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+			if !firstLoop {
+				timeSleep(cfg.System)
+			} else {
+				firstLoop = false
+			}
+
+			if e.gate.full() { //已经有线程获取到orderJobKey，或成功订单数已达上限
+				continue
+			}
+
+			// 服务端要求放慢节奏时，在随机间隔之外继续等待
+			waited, err := pacer.Wait(ctx)
+			if err != nil {
+				return
+			}
+			if waited > 0 {
+				e.logger.Info(logPrefix+"⏳按服务端建议等待后下单", zap.Duration("waited", waited))
+			}
+
+			// 票档已下单成功、售罄或达到限购
+			if !run.begin() {
+				return
+			}
+
+			//下单
+			e.markFirstOrder(index, e.timeSource.Now())
+			orderResp, err := c.Order(ctx, orderReq)
+			if ctx.Err() != nil {
+				run.end()
+				return
+			}
+			if err != nil {
+				e.logger.Error(logPrefix+"下单失败：", zap.Error(err))
+				e.emit(Event{Type: EventOrderFailed, Worker: index, Order: order, Err: err})
+				if client.IsSoldOut(err) || client.IsLimitReached(err) {
+					if run.exhaust(err) {
+						e.logger.Info(logPrefix + "票档售罄或达到限购，等待同票档进行中的订单结束")
+					}
+					return
+				}
+				run.end()
+				continue
+			}
+
+			if hint, ok := orderResp.SleepHint(time.Now()); ok {
+				pacer.Apply(hint)
+				stats := pacer.Stats()
+				e.logger.Info(logPrefix+"🐢服务端建议放慢下单节奏",
+					zap.Duration("sleep", hint.Delay),
+					zap.Time("until", hint.Until),
+					zap.Int("applied", stats.Applied),
+					zap.Int("waits", stats.Waits),
+					zap.Duration("waited", stats.Waited),
+				)
+			}
+
+			orderJobKey := orderResp.Result.OrderJobKey
+			coreOrderKey := orderResp.Result.CoreOrderKey
+			if orderJobKey == "" && coreOrderKey == "" {
+				e.logger.Error(logPrefix + "orderJobKey与coreOrderKey均为空")
+				run.end()
+				continue
+			}
+
+			if !e.gate.acquire() {
+				run.end()
+				continue
+			}
+
+			// 服务端返回 coreOrderKey 时，先通过 coreOrder 确认订单
+			if orderJobKey == "" {
+				e.logger.Info(fmt.Sprintf(logPrefix+"获取coreOrderKey成功！coreOrderKey：%s", coreOrderKey))
+				e.emit(Event{Type: EventOrderAccepted, Worker: index, Order: order, Message: coreOrderKey})
+				orderSn, jobKey, err := confirmCoreOrder(ctx, c, coreOrderKey, cfg)
+				if err != nil {
+					e.logger.Error(logPrefix+"核心订单确认失败：", zap.Error(err))
+					e.gate.release()
+					run.end()
+					continue
+				}
+				if orderSn != "" {
+					e.logger.Info(fmt.Sprintf(logPrefix+"核心订单确认成功！订单号：%s", orderSn))
+					run.finish(orderSn)
+					return
+				}
+				orderJobKey = jobKey
+			}
+
+			e.logger.Info(fmt.Sprintf(logPrefix+"获取orderJobKey成功！orderJobKey：%s", orderJobKey))
+			e.emit(Event{Type: EventOrderAccepted, Worker: index, Order: order, Message: orderJobKey})
+
+			orderSn, err := e.pollOrderResult(ctx, logPrefix, orderJobKey)
+			if err != nil {
+				e.logger.Error(logPrefix+"查询订单结果失败：", zap.Error(err))
+				//释放orderJobKey，继续下单
+				e.gate.release()
+				run.end()
+				continue
+			}
+			e.logger.Info(fmt.Sprintf(logPrefix+"查询订单结果成功！订单号：%s", orderSn))
+			run.finish(orderSn)
+			return
+		}
+	}
+}
+
+// resultThrottleDelay 查询订单结果被限流后，再次查询前的等待时间
+const resultThrottleDelay = 200 * time.Millisecond
+
+// pollOrderResult 查询订单结果：pending 由 client 按轮询策略继续查询，被限流时等待后重试，直到成功或出现其他错误
+func (e *Engine) pollOrderResult(ctx context.Context, logPrefix, orderJobKey string) (string, error) {
+	for {
+		resp, err := e.client.GetOrderResult(ctx, orderJobKey)
+		if err == nil {
+			return resp.Result.OrderSn, nil
+		}
+		// 被限流时不停止查询订单结果
+		if !client.IsThrottled(err) || ctx.Err() != nil {
+			return "", err
+		}
+		e.logger.Warn(logPrefix+"查询订单结果被限流，继续查询", zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(resultThrottleDelay):
+		}
+	}
+}
+
+// confirmCoreOrder 确认 coreOrderKey，返回订单号，或需要继续查询的 orderJobKey。
+// pending 由 client 按轮询策略处理，被限流时间隔后重试，直到 ctx 结束
+func confirmCoreOrder(ctx context.Context, c client.ShowStartIface, coreOrderKey string, cfg *config.Config) (orderSn, orderJobKey string, err error) {
+	for {
+		resp, err := c.CoreOrder(ctx, coreOrderKey)
+		if client.IsThrottled(err) && ctx.Err() == nil {
+			timeSleep(cfg.System)
+			continue
+		}
+		if err != nil {
+			return "", "", err
+		}
+		if resp.Result.OrderSn == "" && resp.Result.OrderJobKey == "" {
+			return "", "", errors.New("coreOrder 未返回订单号或 orderJobKey")
+		}
+		return resp.Result.OrderSn, resp.Result.OrderJobKey, nil
+	}
+}
+
+// timeSleep 在 min_interval 与 max_interval 之间随机休眠
+func timeSleep(cfg *config.System) {
+	// 生成随机休眠时间
+	minInterval := cfg.MinInterval
+	maxInterval := cfg.MaxInterval
+	interval := rand.Intn(maxInterval-minInterval+1) + minInterval
+	time.Sleep(time.Duration(interval) * time.Millisecond)
+}
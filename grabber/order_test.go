@@ -0,0 +1,106 @@
+package grabber
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/staparx/go_showstart/client"
+	"github.com/staparx/go_showstart/client/showstarttest"
+	"github.com/staparx/go_showstart/config"
+)
+
+// orderTimes 模拟服务收到的下单请求时间
+func orderTimes(s *showstarttest.Server) []time.Time {
+	var times []time.Time
+	for _, r := range s.Requests() {
+		if r.Path == "/nj/order/order" {
+			times = append(times, r.Time)
+		}
+	}
+	return times
+}
+
+func TestOrderFollowsSleepHint(t *testing.T) {
+	const hinted = 6
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	// 服务端只下发节奏建议：间隔 200ms，10 秒内有效
+	s.SetSleepHint(200, 10, true)
+
+	e := newTestEngine(t, s, &config.TicketJob{List: []config.TicketList{{Session: testSession, Price: "388"}}})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	type outcome struct {
+		res Result
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		res, err := e.Run(ctx)
+		done <- outcome{res, err}
+	}()
+
+	for s.Count("/nj/order/order") < hinted {
+		select {
+		case <-ctx.Done():
+			t.Fatalf("下单请求 %d 次", s.Count("/nj/order/order"))
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	s.SetSleepHint(0, 0, false)
+
+	out := <-done
+	if out.err != nil || out.res.OrderSn == "" {
+		t.Fatalf("Run: %+v, %v", out.res, out.err)
+	}
+
+	// 收到第一个建议前最多有 MaxGoroutine 个请求已发出，之后的请求间隔不小于建议值
+	times := orderTimes(s)[:hinted]
+	skip := e.cfg.System.MaxGoroutine
+	for i := skip; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < 190*time.Millisecond {
+			t.Errorf("第 %d、%d 次下单间隔 %v，小于服务端建议的 200ms", i, i+1, gap)
+		}
+	}
+}
+
+func TestPollOrderResultSinglePoller(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	s.SetPending(5)
+	s.SetThrottle("/nj/order/getOrderResult", 2)
+
+	// pending 持续约 500ms，超过旧实现每 200ms 再启动一个查询协程的间隔
+	poll := client.DefaultPollPolicy
+	poll.BaseDelay, poll.MaxDelay = 100*time.Millisecond, 100*time.Millisecond
+	e := newTestEngine(t, s, &config.TicketJob{List: []config.TicketList{{Session: testSession, Price: "388"}}}, client.WithPollPolicy(poll))
+	res, err := runEngine(t, e)
+	if err != nil || res.OrderSn == "" {
+		t.Fatalf("Run: %+v, %v", res, err)
+	}
+	// 2 次限流 + 5 次 pending + 1 次成功
+	if n := s.Count("/nj/order/getOrderResult"); n != 8 {
+		t.Errorf("getOrderResult 请求 %d 次, want 8", n)
+	}
+	// 只有一个查询在进行：限流后间隔 200ms，pending 时按轮询策略间隔 100ms
+	var times []time.Time
+	for _, r := range s.Requests() {
+		if r.Path == "/nj/order/getOrderResult" {
+			times = append(times, r.Time)
+		}
+	}
+	for i := 1; i < len(times); i++ {
+		min := 90 * time.Millisecond
+		if i <= 2 {
+			min = 190 * time.Millisecond
+		}
+		if gap := times[i].Sub(times[i-1]); gap < min {
+			t.Errorf("第 %d、%d 次查询间隔 %v，小于 %v", i, i+1, gap, min)
+		}
+	}
+	if n := s.Count("/nj/order/order"); n != 1 {
+		t.Errorf("下单请求 %d 次, want 1", n)
+	}
+}
@@ -0,0 +1,107 @@
+package grabber
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/staparx/go_showstart/vars"
+	"go.uber.org/zap"
+)
+
+// startTimeLayout 配置中抢票时间的格式
+const startTimeLayout = "2006-01-02 15:04:05.000"
+
+// startTimeTolerance 配置的抢票时间与服务端开售时间相差超过该值时告警
+const startTimeTolerance = time.Second
+
+// resolveStartTime 确定票档的抢票时间：优先使用票档配置，其次任务配置，都未配置时使用服务端返回的开售时间
+func (e *Engine) resolveStartTime(ticket *buyTicket) (time.Time, error) {
+	label := fmt.Sprintf("%s - %s", ticket.SessionName, ticket.Ticket.SellingPrice)
+
+	var server time.Time
+	if ticket.Ticket.StartTime > 0 {
+		server = time.UnixMilli(ticket.Ticket.StartTime).In(vars.TimeLocal)
+	}
+
+	configured := ticket.ConfigStartTime
+	if configured == "" {
+		configured = e.job.StartTime
+	}
+	if configured == "" {
+		if server.IsZero() {
+			e.logger.Error("❌ 服务端未返回开售时间，请配置 start_time", zap.String("ticket", label))
+			return time.Time{}, fmt.Errorf("票档 %s 未返回开售时间，请配置 start_time", label)
+		}
+		e.logger.Info(fmt.Sprintf("🕒票档 %s 使用服务端开售时间：%s", label, server.Format(startTimeLayout)))
+		return server, nil
+	}
+
+	t, err := time.ParseInLocation(startTimeLayout, configured, vars.TimeLocal)
+	if err != nil {
+		e.logger.Error("⏰时间格式" + configured + "错误，正确格式为：2006-01-02 15:04:05.000 ")
+		return time.Time{}, err
+	}
+
+	if !server.IsZero() {
+		if diff := t.Sub(server); diff > startTimeTolerance || diff < -startTimeTolerance {
+			e.logger.Warn("⚠️ 配置的抢票时间与服务端开售时间不一致，请确认",
+				zap.String("ticket", label),
+				zap.String("configured", t.Format(startTimeLayout)),
+				zap.String("server", server.Format(startTimeLayout)),
+				zap.Duration("diff", diff),
+			)
+		}
+	}
+	return t, nil
+}
+
+// clockSyncLead 开售前多久开始对时
+const clockSyncLead = 30 * time.Second
+
+// syncClock 开售前对时，估计服务端时钟偏差；对时失败时按本机时间抢票
+func (e *Engine) syncClock(ctx context.Context, start time.Time) {
+	samples := e.cfg.System.ClockSyncSamples
+	if samples < 0 {
+		return
+	}
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(time.Until(start.Add(-clockSyncLead))):
+	}
+
+	// 对时需在开售前完成，不能推迟抢票
+	deadline := start.Add(-time.Second)
+	if !time.Now().Before(deadline) {
+		e.logger.Warn("⚠️ 距离开售时间过近，跳过对时，按本机时间抢票")
+		return
+	}
+	syncCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	offset, err := e.client.SyncClock(syncCtx, samples)
+	if err != nil {
+		if ctx.Err() == nil {
+			e.logger.Warn("⚠️ 对时失败，按本机时间抢票", zap.Error(err))
+		}
+		return
+	}
+	e.clockMu.Lock()
+	e.clock = offset
+	e.clockMu.Unlock()
+	e.logger.Info("⏱️服务端时间偏差估计完成",
+		zap.Duration("offset", offset.Offset),
+		zap.Duration("uncertainty", offset.Uncertainty),
+		zap.Duration("rtt", offset.RTT),
+		zap.Int("samples", offset.Samples),
+		zap.String("launch", e.launchTime(start).Format(startTimeLayout)),
+	)
+}
+
+// localTime 将服务端时间换算为本机时间，未对时时原样返回
+func (e *Engine) localTime(server time.Time) time.Time {
+	e.clockMu.Lock()
+	defer e.clockMu.Unlock()
+	return e.clock.Local(server)
+}
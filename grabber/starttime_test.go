@@ -0,0 +1,109 @@
+package grabber
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/staparx/go_showstart/client"
+	"github.com/staparx/go_showstart/config"
+	"github.com/staparx/go_showstart/vars"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// logCore 记录日志的级别与内容
+type logCore struct {
+	mu      *sync.Mutex
+	entries *[]zapcore.Entry
+}
+
+func newLogCore() logCore {
+	return logCore{mu: &sync.Mutex{}, entries: &[]zapcore.Entry{}}
+}
+
+func (c logCore) Enabled(zapcore.Level) bool        { return true }
+func (c logCore) With([]zapcore.Field) zapcore.Core { return c }
+func (c logCore) Sync() error                       { return nil }
+
+func (c logCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c logCore) Write(ent zapcore.Entry, _ []zapcore.Field) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.entries = append(*c.entries, ent)
+	return nil
+}
+
+// count 指定级别且包含 msg 的日志条数
+func (c logCore) count(level zapcore.Level, msg string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, ent := range *c.entries {
+		if ent.Level == level && strings.Contains(ent.Message, msg) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestResolveStartTime(t *testing.T) {
+	defer func(loc *time.Location) { vars.TimeLocal = loc }(vars.TimeLocal)
+	vars.TimeLocal = time.FixedZone("CST", 8*3600)
+	server := time.Date(2024, 8, 16, 20, 0, 0, 0, vars.TimeLocal)
+	const mismatchWarn = "配置的抢票时间与服务端开售时间不一致"
+
+	tests := []struct {
+		name       string
+		jobStart   string
+		tierStart  string
+		serverTime time.Time
+		want       time.Time
+		wantErr    bool
+		wantWarn   bool
+	}{
+		{name: "未配置时使用服务端开售时间", serverTime: server, want: server},
+		{name: "未配置且服务端未返回", wantErr: true},
+		{name: "任务配置", jobStart: "2024-08-16 20:00:00.500", serverTime: server, want: server.Add(500 * time.Millisecond)},
+		{name: "票档配置优先于任务配置", jobStart: "2024-08-16 19:00:00.000", tierStart: "2024-08-16 20:00:00.200", serverTime: server, want: server.Add(200 * time.Millisecond)},
+		{name: "相差正好 1s 不告警", jobStart: "2024-08-16 19:59:59.000", serverTime: server, want: server.Add(-time.Second)},
+		{name: "晚于开售时间超过 1s 告警", jobStart: "2024-08-16 20:00:01.001", serverTime: server, want: server.Add(1001 * time.Millisecond), wantWarn: true},
+		{name: "早于开售时间超过 1s 告警", tierStart: "2024-08-16 19:59:58.000", serverTime: server, want: server.Add(-2 * time.Second), wantWarn: true},
+		{name: "服务端未返回时不比较", jobStart: "2024-08-16 18:00:00.000", want: server.Add(-2 * time.Hour)},
+		{name: "时间格式错误", jobStart: "2024-08-16 20:00:00", serverTime: server, wantErr: true},
+		{name: "票档时间格式错误", jobStart: "2024-08-16 20:00:00.000", tierStart: "20:00", serverTime: server, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := newLogCore()
+			job := &config.TicketJob{Name: "job", StartTime: tt.jobStart}
+			e := NewEngine(&config.Config{}, job, nil, WithLogger(zap.New(core)))
+			ticket := &buyTicket{
+				SessionName:     testSession,
+				Ticket:          &client.TicketInfo{SellingPrice: "388"},
+				ConfigStartTime: tt.tierStart,
+			}
+			if !tt.serverTime.IsZero() {
+				ticket.Ticket.StartTime = tt.serverTime.UnixMilli()
+			}
+
+			got, err := e.resolveStartTime(ticket)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want 错误", got)
+				}
+				return
+			}
+			if err != nil || !got.Equal(tt.want) {
+				t.Fatalf("got %v, %v, want %v", got, err, tt.want)
+			}
+			if n := core.count(zapcore.WarnLevel, mismatchWarn); (n > 0) != tt.wantWarn {
+				t.Errorf("不一致告警 %d 条, wantWarn %v", n, tt.wantWarn)
+			}
+		})
+	}
+}
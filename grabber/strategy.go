@@ -0,0 +1,242 @@
+package grabber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/staparx/go_showstart/client"
+	"github.com/staparx/go_showstart/config"
+	"go.uber.org/zap"
+)
+
+// errTiersExhausted 所有票档都已售罄或达到限购
+var errTiersExhausted = errors.New("所有票档均已售罄或达到限购")
+
+// tier 已确认订单信息的票档
+type tier struct {
+	order *OrderDetail
+	req   *client.OrderReq
+	price float64
+}
+
+// tierRun 同一票档的下单协程共享的状态
+type tierRun struct {
+	tier  *tier
+	pacer *client.OrderPacer
+	gate  *orderGate
+
+	once sync.Once
+	done chan struct{}
+
+	mu sync.Mutex
+	// busy 正在下单或持有 orderJobKey 的协程数，票档在 busy 归零后才结束，避免丢弃已拿到的订单
+	busy int
+	// stopped 已下单成功、售罄或达到限购，不再发起新的下单
+	stopped  bool
+	orderSns []string
+	err      error
+}
+
+func newTierRun(t *tier, gate *orderGate) *tierRun {
+	return &tierRun{
+		tier: t,
+		// 各协程共享服务端返回的 sleep 节奏建议
+		pacer: client.NewOrderPacer(nil),
+		gate:  gate,
+		done:  make(chan struct{}),
+	}
+}
+
+// begin 发起下单前登记，票档已停止下单时返回 false；之后须调用 end、finish 或 exhaust 之一
+func (r *tierRun) begin() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return false
+	}
+	r.busy++
+	return true
+}
+
+// end 本次下单未拿到订单，结束登记
+func (r *tierRun) end() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.busy--
+	r.closeIfIdle()
+}
+
+// finish 记录下单成功的订单，停止该票档的下单
+func (r *tierRun) finish(orderSn string) {
+	r.gate.done()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.orderSns = append(r.orderSns, orderSn)
+	r.stopped = true
+	r.busy--
+	r.closeIfIdle()
+}
+
+// exhaust 票档售罄或达到限购，停止该票档的下单。其他协程仍在下单或持有 orderJobKey 时
+// 返回 true，等其结束后票档才结束，拿到的订单仍计入结果
+func (r *tierRun) exhaust(err error) (waiting bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+	r.stopped = true
+	r.busy--
+	r.closeIfIdle()
+	return r.busy > 0
+}
+
+// closeIfIdle 停止下单且没有进行中的订单时结束票档，调用方需持有 r.mu
+func (r *tierRun) closeIfIdle() {
+	if r.stopped && r.busy == 0 {
+		r.once.Do(func() { close(r.done) })
+	}
+}
+
+func (r *tierRun) outcome() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.orderSns) > 0 {
+		return append([]string(nil), r.orderSns...), nil
+	}
+	return nil, r.err
+}
+
+// runStrategy 按任务配置的策略抢购各票档，结果通过 succeed / fail 返回
+func (e *Engine) runStrategy(ctx context.Context, tiers []*tier) {
+	strategy := e.job.Strategy.OrDefault()
+	e.logger.Info(fmt.Sprintf("🧭抢购策略：%s，共 %d 个票档", strategy, len(tiers)))
+
+	if strategy == config.StrategyCheapest {
+		tiers = append([]*tier(nil), tiers...)
+		sort.SliceStable(tiers, func(i, j int) bool { return tiers[i].price < tiers[j].price })
+	}
+	if e.dryRunMode() == config.DryRunLog {
+		e.dryRun(tiers)
+		return
+	}
+
+	switch strategy {
+	case config.StrategyParallel:
+		e.runParallel(ctx, tiers)
+	default:
+		e.runSequential(ctx, tiers)
+	}
+}
+
+// runSequential 逐个抢购票档，售罄或达到限购时切换到下一个
+func (e *Engine) runSequential(ctx context.Context, tiers []*tier) {
+	for i, t := range tiers {
+		e.logger.Info(fmt.Sprintf("🎫开始抢购第 %d 个票档：%s - %s", i+1, t.order.SessionName, t.order.Price))
+		orderSns, err := e.runTier(ctx, t)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			e.succeed(placedOrders(t, orderSns))
+			return
+		}
+		msg := "⚠️ 票档售罄或达到限购，切换到下一个票档"
+		if i == len(tiers)-1 {
+			msg = "⚠️ 票档售罄或达到限购，已没有可切换的票档"
+		}
+		e.logger.Warn(msg, zap.String("session", t.order.SessionName), zap.String("price", t.order.Price), zap.Error(err))
+		e.emit(Event{Type: EventTierExhausted, Worker: -1, Order: t.order, Err: err})
+	}
+	e.fail(errTiersExhausted)
+}
+
+// runParallel 同时抢购所有票档，成功订单数达到 max_orders 后停止
+func (e *Engine) runParallel(ctx context.Context, tiers []*tier) {
+	parCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type outcome struct {
+		tier     *tier
+		orderSns []string
+		err      error
+	}
+	outcomes := make(chan outcome, len(tiers))
+	for _, t := range tiers {
+		t := t
+		e.goBackground(func() {
+			orderSns, err := e.runTier(parCtx, t)
+			outcomes <- outcome{tier: t, orderSns: orderSns, err: err}
+		})
+	}
+
+	var placed []PlacedOrder
+	for range tiers {
+		select {
+		case <-ctx.Done():
+			return
+		case out := <-outcomes:
+			if out.err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				e.logger.Warn("⚠️ 票档售罄或达到限购，停止抢购该票档", zap.String("session", out.tier.order.SessionName), zap.String("price", out.tier.order.Price), zap.Error(out.err))
+				e.emit(Event{Type: EventTierExhausted, Worker: -1, Order: out.tier.order, Err: out.err})
+				continue
+			}
+			placed = append(placed, placedOrders(out.tier, out.orderSns)...)
+			if len(placed) >= e.gate.max {
+				if len(placed) > e.gate.max {
+					e.logger.Warn(fmt.Sprintf("⚠️ 成功订单数 %d 超过上限 %d，多余的订单请按需取消", len(placed), e.gate.max))
+				}
+				e.succeed(placed)
+				return
+			}
+			e.logger.Info(fmt.Sprintf("🎫已成功 %d 个订单，上限 %d 个，继续抢购其他票档", len(placed), e.gate.max))
+		}
+	}
+
+	if len(placed) > 0 {
+		e.succeed(placed)
+		return
+	}
+	e.fail(errTiersExhausted)
+}
+
+// runTier 启动票档的下单协程，直到下单成功、票档售罄或达到限购且进行中的订单都已结束，或 ctx 结束
+func (e *Engine) runTier(ctx context.Context, t *tier) ([]string, error) {
+	tierCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// 票档开售时间晚于抢票启动时间时，等到开售再下单
+	if launch := e.launchTime(t.order.StartTime); e.timeSource.Now().Before(launch) {
+		e.logger.Info(fmt.Sprintf("🕒票档 %s - %s 尚未开售，等待至 %s", t.order.SessionName, t.order.Price, t.order.StartTime.Format(startTimeLayout)))
+		if err := e.sleepUntil(ctx, launch); err != nil {
+			return nil, err
+		}
+	}
+
+	run := newTierRun(t, e.gate)
+	for i := 0; i < e.cfg.System.MaxGoroutine; i++ {
+		index := i
+		e.goBackground(func() { e.goOrder(tierCtx, index, run) })
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-run.done:
+		return run.outcome()
+	}
+}
+
+func placedOrders(t *tier, orderSns []string) []PlacedOrder {
+	placed := make([]PlacedOrder, 0, len(orderSns))
+	for _, sn := range orderSns {
+		placed = append(placed, PlacedOrder{Order: t.order, OrderSn: sn})
+	}
+	return placed
+}
@@ -0,0 +1,96 @@
+package grabber
+
+import (
+	"testing"
+	"time"
+
+	"github.com/staparx/go_showstart/client"
+	"github.com/staparx/go_showstart/client/showstarttest"
+	"github.com/staparx/go_showstart/config"
+)
+
+var priorityList = []config.TicketList{
+	{Session: testSession, Price: "388"},
+	{Session: testSession, Price: "588"},
+}
+
+func TestPriorityFallsBackWhenSoldOut(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	s.SetRemain("ticket-388", 0)
+
+	e := newTestEngine(t, s, &config.TicketJob{List: priorityList})
+	res, err := runEngine(t, e)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(res.Orders) != 1 || res.Orders[0].Order.Price != "588" {
+		t.Fatalf("orders = %+v, want 1 个 588 的订单", res.Orders)
+	}
+}
+
+func TestAllTiersExhausted(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	s.SetRemain("ticket-388", 0)
+	s.SetLimitReached("ticket-588", true)
+
+	e := newTestEngine(t, s, &config.TicketJob{List: priorityList})
+	if _, err := runEngine(t, e); err == nil {
+		t.Fatal("所有票档售罄或达到限购时应返回错误")
+	}
+}
+
+// 开售时多个协程同时下单：一个协程拿到最后一张票的 orderJobKey，其他协程收到售罄。
+// 票档应等持有者查询到订单结果，不能切换到下一个票档重复下单
+func TestSoldOutWaitsForHolder(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	s.SetRemain("ticket-388", 1)
+	s.SetPending(4)
+	// 各协程的下单请求都在服务端处理前发出
+	s.SetLatency("/nj/order/order", 50*time.Millisecond)
+
+	poll := client.DefaultPollPolicy
+	poll.BaseDelay, poll.MaxDelay = 50*time.Millisecond, 50*time.Millisecond
+	e := newTestEngine(t, s, &config.TicketJob{List: priorityList}, client.WithPollPolicy(poll))
+	e.cfg.System.MinInterval, e.cfg.System.MaxInterval = 1, 1
+
+	res, err := runEngine(t, e)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if n := s.Count("/nj/order/order"); n < 2 {
+		t.Fatalf("下单请求 %d 次，没有协程收到售罄", n)
+	}
+	if len(res.Orders) != 1 || res.Orders[0].Order.Price != "388" {
+		t.Fatalf("orders = %+v, want 1 个 388 的订单", res.Orders)
+	}
+	if n := s.Remain("ticket-588"); n != 100 {
+		t.Errorf("588 票档余票 %d，不应在 388 已拿到订单时下单", n)
+	}
+	if orders := s.Orders(); len(orders) != 1 || orders[0] != res.OrderSn {
+		t.Errorf("服务端订单 %v, 结果 %s", orders, res.OrderSn)
+	}
+}
+
+func TestParallelMaxOrders(t *testing.T) {
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+
+	e := newTestEngine(t, s, &config.TicketJob{List: priorityList, Strategy: config.StrategyParallel, MaxOrders: 2})
+	res, err := runEngine(t, e)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(res.Orders) != 2 {
+		t.Fatalf("orders = %+v, want 2 个", res.Orders)
+	}
+	prices := map[string]bool{}
+	for _, o := range res.Orders {
+		prices[o.Order.Price] = true
+	}
+	if !prices["388"] || !prices["588"] {
+		t.Errorf("orders = %+v, want 388 与 588 各 1 个", res.Orders)
+	}
+}
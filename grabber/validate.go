@@ -0,0 +1,223 @@
+package grabber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/staparx/go_showstart/client"
+	"github.com/staparx/go_showstart/config"
+	"go.uber.org/zap"
+)
+
+type buyTicket struct {
+	ActivityName                string             `json:"activityName"`
+	SessionName                 string             `json:"sessionName"`
+	SessionID                   int                `json:"sessionId"`
+	IsConfirmedStartTime        int                `json:"isConfirmedStartTime"`
+	CommonPerformerDocumentType string             `json:"commonPerformerDocumentType"`
+	IsSupportTransform          int                `json:"isSupportTransform"`
+	Ticket                      *client.TicketInfo `json:"ticket"`
+	// ConfigStartTime 票档配置的抢票时间，为空时使用任务配置或服务端的开售时间
+	ConfigStartTime string `json:"-"`
+	// StartTime 最终确定的抢票时间
+	StartTime time.Time `json:"-"`
+}
+
+// validate 前置检查操作：获取 token、查询活动与票档，并按配置匹配抢购的票档
+func (e *Engine) validate(ctx context.Context) ([]*buyTicket, error) {
+	c := e.client
+
+	activityId := e.job.ActivityId
+
+	err := c.GetToken(ctx)
+	if err != nil {
+		e.logger.Error("获取登陆token失败", zap.Error(err))
+		return nil, err
+	}
+	e.logger.Info("👌获取登陆token成功")
+
+	e.logger.Info("🏃正在查询活动详情信息...")
+	//获取活动详情
+	detail, err := c.ActivityDetail(ctx, activityId)
+	if err != nil {
+		e.logger.Error("❌ 查询活动详情信息失败", zap.Error(err))
+		return nil, err
+	}
+	e.logger.Info("🎯查询到activity_id对应的活动名称为:")
+	e.logger.Info("==============================================")
+	e.logger.Info(detail.Result.ActivityName)
+	e.logger.Info("==============================================")
+
+	//查询票务信息
+	e.logger.Info("🏃正在查询活动的票务信息...")
+	ticketList, err := c.ActivityTicketList(ctx, activityId)
+	if err != nil {
+		e.logger.Error("❌ 查询活动票务信息失败", zap.Error(err))
+		return nil, err
+	}
+
+	//按顺序查找票务信息
+	buyTicketList := matchTickets(e.job.List, detail.Result.ActivityName, ticketList.Result)
+	if len(buyTicketList) == 0 {
+		e.logger.Error("❌ 配置匹配票档失败！在场次中未找寻到对应票价的信息")
+		// 多个任务同时运行或没有终端时无法交互输入，列出可选票档后结束该任务
+		if !e.job.Legacy || !e.cfg.System.IsInteractive() {
+			for _, session := range ticketList.Result {
+				for _, ticketPrice := range session.TicketPriceList {
+					e.logger.Info(fmt.Sprintf("🎯可选票档：session: \"%s\" price: \"%s\"", session.SessionName, ticketPrice.Price))
+				}
+			}
+			return nil, errors.New("匹配票档失败！在场次中未找寻到对应票价的信息，请按可选票档修改任务配置")
+		}
+		ticket, err := e.manualMatch(detail.Result.ActivityName, ticketList.Result)
+		if err != nil {
+			return nil, err
+		}
+		buyTicketList = append(buyTicketList, ticket)
+	}
+
+	e.logger.Info("🎫获取票务信息成功，系统将按照以下优先级进行抢购:")
+	e.logger.Info("==============================================")
+	for _, v := range buyTicketList {
+		e.logger.Info(fmt.Sprintf("%s - %s - %s - %s", v.SessionName, v.Ticket.TicketType, v.Ticket.CostPrice, v.Ticket.SaleStatus))
+		if !v.Ticket.SaleStatus.Known() {
+			e.logger.Warn("⚠️ 未收录的售卖状态，请反馈该活动以便补充", zap.Stringer("saleStatus", v.Ticket.SaleStatus), zap.String("ticketId", v.Ticket.TicketID))
+		}
+		if !v.Ticket.TicketType.Known() {
+			e.logger.Warn("⚠️ 未收录的票类型，无法判断是否需要收货地址", zap.Stringer("ticketType", v.Ticket.TicketType), zap.String("ticketId", v.Ticket.TicketID))
+		}
+	}
+	e.logger.Info("==============================================")
+
+	for _, v := range buyTicketList {
+		v.StartTime, err = e.resolveStartTime(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buyTicketList, nil
+}
+
+// matchTickets 按配置的顺序在票务信息中查找场次与票价，未找到或没有票档信息的配置项跳过
+func matchTickets(list []config.TicketList, activityName string, sessions []*client.ActivityTicket) []*buyTicket {
+	var buyTicketList []*buyTicket
+	for _, ticket := range list {
+		for _, result := range sessions {
+			//找到对应的场次
+			if DelectStringBlank(result.SessionName) == DelectStringBlank(ticket.Session) {
+				//找到对应的票价
+				for _, ticketPrice := range result.TicketPriceList {
+					// 票价下没有票档信息时无法下单，按未匹配处理
+					if ticket.Price == ticketPrice.Price && len(ticketPrice.TicketList) > 0 {
+						//将场次票价信息保存下来
+						buyTicketList = append(buyTicketList, &buyTicket{
+							ActivityName:                activityName,
+							SessionName:                 result.SessionName,
+							SessionID:                   result.SessionID,
+							IsConfirmedStartTime:        result.IsConfirmedStartTime,
+							CommonPerformerDocumentType: result.CommonPerformerDocumentType,
+							IsSupportTransform:          result.IsSupportTransform,
+							Ticket:                      ticketPrice.TicketList[0],
+							ConfigStartTime:             ticket.StartTime,
+						})
+					}
+				}
+			}
+		}
+	}
+	return buyTicketList
+}
+
+// DelectStringBlank 函数移除字符串中的所有空格
+func DelectStringBlank(s string) string {
+	return strings.ReplaceAll(s, " ", "")
+}
+
+// manualMatch 手动匹配模式：在终端中选择场次与票价，并保存到配置文件
+func (e *Engine) manualMatch(activityName string, sessions []*client.ActivityTicket) (*buyTicket, error) {
+	e.logger.Info("🎯进入手动匹配模式，请根据以下信息进行匹配:")
+	if len(sessions) == 0 {
+		return nil, errors.New("匹配票档失败！活动暂无可选场次")
+	}
+
+	sessionIndex := 0
+	if len(sessions) == 1 { // 单场次
+		e.logger.Info("🎯仅有一个场次，默认匹配，场次名为:" + sessions[0].SessionName)
+	} else { // 多场次
+		e.logger.Info("🎯有多个场次，请手动匹配")
+		for index, session := range sessions {
+			e.logger.Info(fmt.Sprintf("🎯场次%d：%s", index+1, session.SessionName))
+		}
+		var err error
+		if sessionIndex, err = e.promptIndex("场次", len(sessions)); err != nil {
+			return nil, err
+		}
+	}
+	session := sessions[sessionIndex]
+
+	// 只保留有票的票价
+	var prices []int
+	for index, ticketPrice := range session.TicketPriceList {
+		if len(ticketPrice.TicketList) > 0 {
+			prices = append(prices, index)
+		}
+	}
+	if len(prices) == 0 {
+		return nil, fmt.Errorf("匹配票档失败！场次 %s 暂无可选票价", session.SessionName)
+	}
+
+	priceIndex := 0
+	if len(prices) == 1 { // 单票价
+		e.logger.Info("🎯仅有一个票价，默认匹配，票价为:" + session.TicketPriceList[prices[0]].Price)
+	} else { // 多票价
+		e.logger.Info("🎯有多个票价，请手动匹配")
+		for index, i := range prices {
+			e.logger.Info(fmt.Sprintf("🎯票价%d：%s", index+1, session.TicketPriceList[i].Price))
+		}
+		var err error
+		if priceIndex, err = e.promptIndex("票价", len(prices)); err != nil {
+			return nil, err
+		}
+	}
+	price := session.TicketPriceList[prices[priceIndex]]
+
+	if err := config.SaveCfg(session.SessionName, price.Price); err != nil { // 保存配置到config.yaml
+		e.logger.Error("❌ 保存手动匹配配置信息失败", zap.Error(err))
+	} else {
+		e.logger.Info("🎯保存手动匹配配置信息成功")
+	}
+	return &buyTicket{
+		ActivityName:                activityName,
+		SessionName:                 session.SessionName,
+		SessionID:                   session.SessionID,
+		IsConfirmedStartTime:        session.IsConfirmedStartTime,
+		CommonPerformerDocumentType: session.CommonPerformerDocumentType,
+		IsSupportTransform:          session.IsSupportTransform,
+		Ticket:                      price.TicketList[0],
+	}, nil
+}
+
+// promptIndex 读取 1~n 的序号，输入无效时重新输入，返回从 0 开始的下标
+func (e *Engine) promptIndex(name string, n int) (int, error) {
+	for {
+		e.logger.Info(fmt.Sprintf("🎯请输入%s序号（1-%d）:", name, n))
+		line, err := e.input.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" && err != nil {
+			return 0, fmt.Errorf("读取%s序号失败，没有终端时请设置 system.interactive: false 并按可选票档修改配置：%w", name, err)
+		}
+		index, convErr := strconv.Atoi(line)
+		if convErr == nil && index >= 1 && index <= n {
+			return index - 1, nil
+		}
+		e.logger.Warn(fmt.Sprintf("⚠️ 无效的%s序号 %q，请输入 1-%d 之间的数字", name, line, n))
+		if err != nil {
+			return 0, fmt.Errorf("读取%s序号失败：%w", name, err)
+		}
+	}
+}
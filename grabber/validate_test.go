@@ -0,0 +1,52 @@
+package grabber
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/staparx/go_showstart/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestPromptIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int
+		wantErr bool
+		// retries 重新输入的次数
+		retries int
+	}{
+		{name: "有效输入", input: "2\n", want: 1},
+		{name: "首尾空白", input: "  3 \r\n", want: 2},
+		{name: "最后一行没有换行", input: "1", want: 0},
+		{name: "无效输入后重新输入", input: "abc\n\n2\n", want: 1, retries: 2},
+		{name: "超出范围后重新输入", input: "0\n4\n-1\n3\n", want: 2, retries: 3},
+		{name: "EOF", input: "", wantErr: true},
+		{name: "无效输入后 EOF", input: "9\n", wantErr: true, retries: 1},
+		{name: "无效输入且没有换行", input: "x", wantErr: true, retries: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := newLogCore()
+			e := NewEngine(&config.Config{}, &config.TicketJob{Name: "job"}, nil, WithLogger(zap.New(core)))
+			e.input = bufio.NewReader(strings.NewReader(tt.input))
+
+			got, err := e.promptIndex("场次", 3)
+			if tt.wantErr {
+				if !errors.Is(err, io.EOF) {
+					t.Fatalf("got %d, %v, want EOF", got, err)
+				}
+			} else if err != nil || got != tt.want {
+				t.Fatalf("got %d, %v, want %d", got, err, tt.want)
+			}
+			if n := core.count(zapcore.WarnLevel, "无效的场次序号"); n != tt.retries {
+				t.Errorf("提示重新输入 %d 次, want %d", n, tt.retries)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package log
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/staparx/go_showstart/util"
+	"go.uber.org/zap/zapcore"
+)
+
+// UnsafePlaintextEnv 设置为 1/true 时关闭日志脱敏，仅用于本地调试
+const UnsafePlaintextEnv = "SHOWSTART_UNSAFE_LOG_PLAINTEXT"
+
+// redactEnabled 是否对日志脱敏，默认开启
+var redactEnabled atomic.Bool
+
+func init() {
+	redactEnabled.Store(!envTrue(os.Getenv(UnsafePlaintextEnv)))
+}
+
+// SetUnsafePlaintext 关闭（true）或恢复（false）日志脱敏；环境变量开启时始终不脱敏
+func SetUnsafePlaintext(unsafe bool) {
+	redactEnabled.Store(!unsafe && !envTrue(os.Getenv(UnsafePlaintextEnv)))
+}
+
+// RedactEnabled 当前是否对日志脱敏
+func RedactEnabled() bool {
+	return redactEnabled.Load()
+}
+
+func envTrue(v string) bool {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// redactCore 在写入前对敏感字段、header 与消息中的 JSON 脱敏
+type redactCore struct {
+	zapcore.Core
+}
+
+func newRedactCore(core zapcore.Core) zapcore.Core {
+	return &redactCore{Core: core}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if redactEnabled.Load() {
+		ent.Message = redactMessage(ent.Message)
+	}
+	return c.Core.Write(ent, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	if !redactEnabled.Load() || len(fields) == 0 {
+		return fields
+	}
+	res := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		res[i] = redactField(f)
+	}
+	return res
+}
+
+func redactField(f zapcore.Field) zapcore.Field {
+	if util.IsSensitiveKey(f.Key) {
+		if f.Type == zapcore.StringType {
+			f.String = util.Mask(f.String)
+			return f
+		}
+		return zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: "***"}
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = redactMessage(f.String)
+	case zapcore.ByteStringType:
+		if b, ok := f.Interface.([]byte); ok {
+			f.Interface = util.RedactJSON(b)
+		}
+	case zapcore.ReflectType:
+		switch v := f.Interface.(type) {
+		case http.Header:
+			f.Interface = util.RedactHeader(v)
+		case map[string]string:
+			redacted := make(map[string]string, len(v))
+			for key, value := range v {
+				if util.IsSensitiveKey(key) {
+					value = util.Mask(value)
+				}
+				redacted[key] = value
+			}
+			f.Interface = redacted
+		}
+	}
+	return f
+}
+
+// redactMessage 对消息中嵌入的 JSON（如 "Order:{...}"）脱敏
+func redactMessage(msg string) string {
+	idx := strings.IndexAny(msg, "{[")
+	if idx < 0 {
+		return msg
+	}
+	return msg[:idx] + string(util.RedactJSON([]byte(msg[idx:])))
+}
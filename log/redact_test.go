@@ -0,0 +1,43 @@
+package log
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRedactCoreMasksCredentials(t *testing.T) {
+	const (
+		token = "abcdefghijklmnopqrstuvwxyz0123456789"
+		sign  = "f65f2dbcd7387b2376bcefe13754856b"
+	)
+	var buf bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zapcore.DebugLevel)
+	logger := zap.New(newRedactCore(core))
+
+	body := `{"orderDetails":[{"skuId":"ticket-388"}],"st_flpv":"kgUBxCv3t7EN1wWr6Yeo","sign":"` + sign + `","trackPath":""}`
+	header := http.Header{}
+	header.Set("cusut", sign)
+	header.Set("cuuserref", token)
+	header.Set("cdeviceno", token)
+	header.Set("cterminal", "wap")
+
+	// 与 DebugLogInterceptor、dry-run 日志的写法一致
+	logger.Debug("请求", zap.String("path", "/nj/order/order"), zap.ByteString("request", []byte(body)), zap.Any("header", header))
+	logger.Info("Order:" + body)
+	logger.Info("配置", zap.String("token", token), zap.String("sign", sign))
+
+	out := buf.String()
+	for _, secret := range []string{token, sign} {
+		if strings.Contains(out, secret) {
+			t.Errorf("日志包含明文 %q:\n%s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "ticket-388") || !strings.Contains(out, `"Cterminal":["wap"]`) {
+		t.Errorf("非敏感内容丢失:\n%s", out)
+	}
+}
@@ -65,10 +65,11 @@ func getEncoderCore() (core zapcore.Core) {
 		return
 	}
 
+	// 每个输出单独包裹脱敏，关闭方式见 SetUnsafePlaintext
 	return zapcore.NewTee(
-		zapcore.NewCore(getEncoder(), infoWriter, infoLevel),
-		zapcore.NewCore(getEncoder(), errWriter, errLevel),
-		zapcore.NewCore(getEncoder(), debugWriter, debugLevel),
+		newRedactCore(zapcore.NewCore(getEncoder(), infoWriter, infoLevel)),
+		newRedactCore(zapcore.NewCore(getEncoder(), errWriter, errLevel)),
+		newRedactCore(zapcore.NewCore(getEncoder(), debugWriter, debugLevel)),
 	)
 }
 
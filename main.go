@@ -16,10 +16,17 @@ import (
 )
 
 func main() {
-	// 用于结束程序
+	if code, ok := runCommand(os.Args[1:]); ok {
+		os.Exit(code)
+	}
+
+	// 用于结束程序：交互模式下等待按回车退出，避免双击运行时窗口直接关闭
+	interactive := config.StdinIsTerminal()
 	defer func() {
-		fmt.Println("Press Enter to exit...")
-		fmt.Scanln()
+		if interactive {
+			fmt.Println("Press Enter to exit...")
+			fmt.Scanln()
+		}
 	}()
 	ctx := context.Background()
 
@@ -48,6 +55,13 @@ func main() {
 		log.Logger.Error("❌ 配置信息读取失败：", zap.Error(err))
 		return
 	}
+	interactive = cfg.System.IsInteractive()
+	if cfg.System != nil && cfg.System.UnsafeLogPlaintext {
+		log.SetUnsafePlaintext(true)
+	}
+	if !log.RedactEnabled() {
+		log.Logger.Warn("⚠️ 日志脱敏已关闭，日志中将包含 token、手机号、证件号等明文信息，请勿分享日志文件")
+	}
 	log.Logger.Info("✅ 系统初始化配置完成！")
 
 	if cfg.Monitor != nil && cfg.Monitor.Enable {
@@ -99,67 +113,10 @@ func main() {
 	}
 
 	log.Logger.Info("👍开始进入到票务系统抢票流程！！！")
-	validate := NewValidateService(ctx, cfg)
-	buyTicketList, err := validate.ValidateSystem(ctx)
-	if err != nil {
-		log.Logger.Error("❌ 配置信息校验失败！！！程序结束", zap.Error(err))
-		return
-	}
-
-	cancelCtx, cancel := context.WithCancel(ctx)
-	for _, ticket := range buyTicketList {
-		err = ConfirmOrder(cancelCtx, &OrderDetail{
-			ActivityName: ticket.ActivityName,
-			SessionName:  ticket.SessionName,
-			Price:        ticket.Ticket.SellingPrice,
-			ActivityID:   cfg.Ticket.ActivityId,
-			GoodType:     ticket.Ticket.GoodType,
-			TicketID:     ticket.Ticket.TicketID,
-		}, cfg)
-		if err != nil {
-			log.Logger.Error("❌ 抢票失败！！！程序结束")
-			return
-		}
-	}
 
 	// 捕获终止信号
-	stopChan := make(chan os.Signal, 1)
-	signal.Notify(stopChan, syscall.SIGINT, syscall.SIGTERM)
-
-	select {
-	case order := <-channel:
-		cancel()
-		log.Logger.Info("🎉抢票成功！赶紧去订单页面支付吧！！🎉")
-		// 下单成功，发送邮件提醒
-		if cfg.SmtpEmail.Enable {
-			subject := vars.GetEmailTitle()
-
-			body := vars.GetEmailFormat(order.ActivityName, order.SessionName, order.Price)
-
-			if err := sendEmail(subject, body, cfg); err != nil {
-				log.Logger.Error("发送邮件失败：", zap.Error(err))
-			} else {
-				log.Logger.Info("下单成功，邮件已发送")
-			}
-		}
-	case Error := <-ErrorChannel:
-		cancel()
-		log.Logger.Error("❌ 抢票失败！！！程序结束")
-		// 下单失败，发送邮件提醒
-		if cfg.SmtpEmail.Enable {
-			subject := "抢票初始化失败，请查看错误，并及时处理重启程序！！！"
-
-			body := fmt.Sprintf("错误信息：%s", Error.Error())
-
-			if err := sendEmail(subject, body, cfg); err != nil {
-				log.Logger.Error("发送邮件失败：", zap.Error(err))
-			} else {
-				log.Logger.Info("下单失败，邮件已发送")
-			}
-		}
-	case <-stopChan:
-		log.Logger.Info("⚠️ 接收到关闭信号，程序关闭")
-		cancel()
-		return
-	}
+	runCtx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	runGrab(runCtx, cfg)
 }
@@ -31,7 +31,6 @@ func NewService(ctx context.Context, cfg *config.Config) (*Service, error) {
 		return nil, fmt.Errorf("缺少 showstart 配置")
 	}
 
-	cl := client.NewShowStartClient(ctx, cfg.Showstart)
 	state, err := NewStateManager(cfg.Monitor.StateDir)
 	if err != nil {
 		return nil, err
@@ -46,14 +45,21 @@ func NewService(ctx context.Context, cfg *config.Config) (*Service, error) {
 		loc = time.FixedZone("CST", 8*3600)
 	}
 
-	return &Service{
-		client:   cl,
+	s := &Service{
 		state:    state,
 		notifier: NewNotifier(cfg.Monitor.WebhookURL, cfg.Monitor.AlertWebhookURL),
 		cfg:      cfg.Monitor,
 		interval: interval,
 		location: loc,
-	}, nil
+	}
+
+	var opts []client.Option
+	if cfg.Showstart.SchemaDrift {
+		opts = append(opts, client.WithDriftDetector(client.NewDriftDetector(s.onDrift)))
+	}
+	s.client = client.NewShowStartClient(cfg.Showstart, opts...)
+
+	return s, nil
 }
 
 func (s *Service) Run(ctx context.Context) error {
@@ -207,6 +213,20 @@ func (s *Service) alert(message string) {
 	}
 }
 
+// onDrift 接口返回结构变化，同一特征只在首次出现时告警（跨次运行）
+func (s *Service) onDrift(drifts []client.Drift) {
+	var fresh []string
+	for _, d := range drifts {
+		log.Logger.Warn("🧬接口返回结构变化", zap.String("path", d.Path), zap.String("field", d.Field), zap.String("kind", string(d.Kind)), zap.String("expected", d.Expected), zap.String("actual", d.Actual))
+		if s.state.MarkDrift(d.Signature()) {
+			fresh = append(fresh, d.String())
+		}
+	}
+	if len(fresh) > 0 {
+		s.alert("秀动接口返回结构发生变化：\n" + strings.Join(fresh, "\n"))
+	}
+}
+
 func normalizeKeyword(input string) string {
 	return strings.TrimSpace(strings.ToLower(removeSpecialChars(input)))
 }
@@ -0,0 +1,131 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/staparx/go_showstart/client/showstarttest"
+	"github.com/staparx/go_showstart/config"
+	"github.com/staparx/go_showstart/log"
+	"go.uber.org/zap"
+)
+
+// alertRecorder 记录收到的告警
+type alertRecorder struct {
+	mu     sync.Mutex
+	alerts []string
+}
+
+func (a *alertRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Content struct {
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	data, _ := io.ReadAll(r.Body)
+	_ = json.Unmarshal(data, &payload)
+	a.mu.Lock()
+	a.alerts = append(a.alerts, payload.Content.Text)
+	a.mu.Unlock()
+}
+
+func (a *alertRecorder) list() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.alerts...)
+}
+
+func newDriftService(t *testing.T, s *showstarttest.Server, stateDir, alertURL string) *Service {
+	t.Helper()
+	cfg := &config.Config{
+		Showstart: &config.Showstart{
+			Sign:        "sig",
+			Token:       "abcdefghijklmnopqrstuvwxyz0123456789",
+			Cterminal:   "wap",
+			Cversion:    "997",
+			Cusid:       "1",
+			Cusname:     "nil",
+			BaseURL:     s.BaseURL,
+			SchemaDrift: true,
+		},
+		Monitor: &config.Monitor{Enable: true, StateDir: stateDir, AlertWebhookURL: alertURL},
+	}
+	svc, err := NewService(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.client.GetToken(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestSchemaDriftPersistedAndAlertedOnce(t *testing.T) {
+	if log.Logger == nil {
+		log.Logger = zap.NewNop()
+	}
+	s := showstarttest.NewServer(nil)
+	defer s.Close()
+	alerts := &alertRecorder{}
+	alertSrv := httptest.NewServer(alerts)
+	defer alertSrv.Close()
+	stateDir := t.TempDir()
+
+	// 搜索结果中出现未定义的字段
+	drifted := showstarttest.Reply{State: "1", Success: true, Result: map[string]interface{}{
+		"activityInfo": []interface{}{map[string]interface{}{
+			"activityId": 1, "title": "t", "showTime": "", "siteName": "", "otherLabels": []interface{}{}, "soldOut": true,
+		}},
+	}}
+	search := func(svc *Service) {
+		t.Helper()
+		s.Enqueue("/wap/activity/list", drifted)
+		if _, err := svc.client.ActivitySearchList(context.Background(), "", "t"); err != nil {
+			t.Fatalf("ActivitySearchList: %v", err)
+		}
+	}
+
+	svc := newDriftService(t, s, stateDir, alertSrv.URL)
+	search(svc)
+	got := alerts.list()
+	if len(got) != 1 || !strings.Contains(got[0], "activityInfo[].soldOut") {
+		t.Fatalf("alerts = %q, want 1 条包含 soldOut 的告警", got)
+	}
+
+	data, err := os.ReadFile(filepath.Join(stateDir, "schema_drift.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var signatures []string
+	if err := json.Unmarshal(data, &signatures); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, sig := range signatures {
+		found = found || strings.Contains(sig, "activityInfo[].soldOut|new_field")
+	}
+	if !found {
+		t.Errorf("schema_drift.json = %s", data)
+	}
+
+	// 同一进程内重复出现不再告警
+	search(svc)
+	if n := len(alerts.list()); n != 1 {
+		t.Fatalf("重复出现后告警 %d 条, want 1", n)
+	}
+
+	// 重新启动后读取 schema_drift.json，已告警过的变化不再告警
+	restarted := newDriftService(t, s, stateDir, alertSrv.URL)
+	search(restarted)
+	if n := len(alerts.list()); n != 1 {
+		t.Fatalf("重启后告警 %d 条, want 1", n)
+	}
+}
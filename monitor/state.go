@@ -16,9 +16,11 @@ type StateManager struct {
 	seenPath    string
 	timedPath   string
 	initPath    string
+	driftPath   string
 	mux         sync.RWMutex
 	seen        map[string]struct{}
 	timed       map[string]struct{}
+	drift       map[string]struct{}
 	initialized bool
 }
 
@@ -34,8 +36,10 @@ func NewStateManager(dir string) (*StateManager, error) {
 		seenPath:  filepath.Join(dir, "seen_events.json"),
 		timedPath: filepath.Join(dir, "timed_purchase.json"),
 		initPath:  filepath.Join(dir, "initialized.flag"),
+		driftPath: filepath.Join(dir, "schema_drift.json"),
 		seen:      map[string]struct{}{},
 		timed:     map[string]struct{}{},
+		drift:     map[string]struct{}{},
 	}
 
 	if err := mgr.load(); err != nil {
@@ -96,6 +100,20 @@ func (s *StateManager) MarkTimed(id string) {
 	s.persist()
 }
 
+// MarkDrift 记录接口结构变化的特征，首次出现时返回 true
+func (s *StateManager) MarkDrift(signature string) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if _, ok := s.drift[signature]; ok {
+		return false
+	}
+	s.drift[signature] = struct{}{}
+	if err := s.writeFile(s.driftPath, s.drift); err != nil {
+		log.Logger.Error("写入 schema drift 状态失败", zap.Error(err))
+	}
+	return true
+}
+
 func (s *StateManager) BatchMark(seenIDs, timedIDs []string) {
 	s.mux.Lock()
 	defer s.mux.Unlock()
@@ -121,6 +139,9 @@ func (s *StateManager) load() error {
 	if err := s.readFile(s.timedPath, &s.timed); err != nil {
 		return err
 	}
+	if err := s.readFile(s.driftPath, &s.drift); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"crypto/aes"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 )
 
 // PKCS7Padding pads the plaintext to a multiple of the block size
@@ -13,11 +16,22 @@ func PKCS7Padding(ciphertext []byte, blockSize int) []byte {
 	return append(ciphertext, padtext...)
 }
 
-// PKCS7UnPadding unpads the plaintext
-func PKCS7UnPadding(origData []byte) []byte {
+// PKCS7UnPadding removes and validates PKCS7 padding
+func PKCS7UnPadding(origData []byte, blockSize int) ([]byte, error) {
 	length := len(origData)
+	if length == 0 || length%blockSize != 0 {
+		return nil, fmt.Errorf("invalid padded data length %d", length)
+	}
 	unpadding := int(origData[length-1])
-	return origData[:(length - unpadding)]
+	if unpadding == 0 || unpadding > blockSize || unpadding > length {
+		return nil, fmt.Errorf("invalid padding size %d", unpadding)
+	}
+	for _, b := range origData[length-unpadding:] {
+		if int(b) != unpadding {
+			return nil, errors.New("invalid padding bytes")
+		}
+	}
+	return origData[:(length - unpadding)], nil
 }
 
 // AESEncrypt encrypts plaintext using AES algorithm in ECB mode
@@ -39,6 +53,47 @@ func AESEncrypt(plainText, key string) (string, error) {
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
+// AESDecrypt decrypts base64 ciphertext produced by AESEncrypt
+func AESDecrypt(cipherText, key string) (string, error) {
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(cipherText)
+	if err != nil {
+		return "", err
+	}
+	if len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return "", fmt.Errorf("invalid ciphertext length %d", len(data))
+	}
+
+	plainText := make([]byte, len(data))
+	for bs, be := 0, block.BlockSize(); bs < len(data); bs, be = bs+block.BlockSize(), be+block.BlockSize() {
+		block.Decrypt(plainText[bs:be], data[bs:be])
+	}
+
+	plainText, err = PKCS7UnPadding(plainText, block.BlockSize())
+	if err != nil {
+		return "", err
+	}
+	return string(plainText), nil
+}
+
+// DecryptRequestBody decrypts a {"q":"..."} request body with the key derived from traceId and token
+func DecryptRequestBody(body, traceId, token string) (string, error) {
+	var wrapped struct {
+		Q string `json:"q"`
+	}
+	if err := json.Unmarshal([]byte(body), &wrapped); err != nil {
+		return "", err
+	}
+	if wrapped.Q == "" {
+		return "", errors.New(`missing "q" field`)
+	}
+	return AESDecrypt(wrapped.Q, GenerateKey(traceId, token))
+}
+
 func GenerateKey(t, p string) string {
 	key := ""
 
@@ -0,0 +1,143 @@
+package util
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+const (
+	// testTraceId 与 GenerateTraceId(32) 等长：32 位随机串加 13 位毫秒时间戳
+	testTraceId = "0123456789abcdefghijklmnopqrstuv1723816800123"
+	// testKey GenerateKey(testTraceId, testToken) 的结果
+	testKey = "1almst13aghlors1"
+)
+
+// encryptBlocks 不做填充直接按 ECB 加密，用于构造填充错误的密文
+func encryptBlocks(t *testing.T, plain []byte) string {
+	t.Helper()
+	block, err := aes.NewCipher([]byte(testKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]byte, len(plain))
+	for bs := 0; bs < len(plain); bs += block.BlockSize() {
+		block.Encrypt(out[bs:bs+block.BlockSize()], plain[bs:bs+block.BlockSize()])
+	}
+	return base64.StdEncoding.EncodeToString(out)
+}
+
+func TestAESRoundTrip(t *testing.T) {
+	for _, plain := range []string{"", "a", "0123456789abcde", "0123456789abcdef", `{"activityId":123456,"st_flpv":"` + testFlpv + `"}`} {
+		enc, err := AESEncrypt(plain, testKey)
+		if err != nil {
+			t.Fatalf("AESEncrypt(%q): %v", plain, err)
+		}
+		dec, err := AESDecrypt(enc, testKey)
+		if err != nil || dec != plain {
+			t.Errorf("AESDecrypt(AESEncrypt(%q)) = %q, %v", plain, dec, err)
+		}
+	}
+}
+
+func TestAESDecrypt(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr string
+	}{
+		// 期望值由 openssl enc -aes-128-ecb 独立生成
+		{name: "openssl 向量", in: "JL/dpwEgtMPTH7o9ez6uW5DbotKch3HmFHOk+3GaILe728tP04Q2LtKD5h3hn3sLG+YoTc1ulE1E+GYzy5ibLg==", want: `{"activityId":123456,"st_flpv":"` + testFlpv + `"}`},
+		{name: "整块填充", in: "uGV8YiD8A47cbkLOswxNPjifQM1s0gVCpaYRizqEO4c=", want: "0123456789abcdef"},
+		{name: "空输入", in: "", wantErr: "invalid ciphertext length 0"},
+		{name: "非 base64", in: "not base64!", wantErr: "illegal base64"},
+		{name: "长度不是块大小的整数倍", in: base64.StdEncoding.EncodeToString(make([]byte, 10)), wantErr: "invalid ciphertext length 10"},
+		{name: "缺少填充", in: "uGV8YiD8A47cbkLOswxNPg==", wantErr: "invalid padding"},
+		{name: "填充字节为 0", in: encryptBlocks(t, append([]byte("0123456789abcde"), 0)), wantErr: "invalid padding size 0"},
+		{name: "填充字节大于 16", in: encryptBlocks(t, append([]byte("0123456789abcde"), 17)), wantErr: "invalid padding size 17"},
+		{name: "填充字节不一致", in: encryptBlocks(t, append([]byte("0123456789abcd"), 1, 2)), wantErr: "invalid padding bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AESDecrypt(tt.in, testKey)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want 包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("AESDecrypt = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPKCS7UnPadding(t *testing.T) {
+	block := []byte("0123456789abcdef")
+	tests := []struct {
+		name string
+		in   []byte
+		want []byte
+		ok   bool
+	}{
+		{"一字节填充", append([]byte("0123456789abcde"), 1), []byte("0123456789abcde"), true},
+		{"整块填充", append(append([]byte{}, block...), bytes.Repeat([]byte{16}, 16)...), block, true},
+		{"空输入", nil, nil, false},
+		{"长度不是块大小的整数倍", []byte("0123456789"), nil, false},
+		{"填充字节为 0", append([]byte("0123456789abcde"), 0), nil, false},
+		{"填充字节大于 16", append([]byte("0123456789abcde"), 17), nil, false},
+		{"填充字节不一致", append([]byte("0123456789abc"), 2, 3, 3), nil, false},
+	}
+	for _, tt := range tests {
+		got, err := PKCS7UnPadding(tt.in, aes.BlockSize)
+		if (err == nil) != tt.ok || !bytes.Equal(got, tt.want) {
+			t.Errorf("%s: PKCS7UnPadding = %q, %v", tt.name, got, err)
+		}
+	}
+}
+
+func TestGenerateKey(t *testing.T) {
+	if got := GenerateKey(testTraceId, testToken); got != testKey {
+		t.Errorf("GenerateKey = %q, want %q", got, testKey)
+	}
+	// 过短的 traceId 不会越界
+	if got := GenerateKey("0123", testToken); got != "1aghlors1" {
+		t.Errorf("GenerateKey = %q", got)
+	}
+}
+
+func TestDecryptRequestBody(t *testing.T) {
+	plain := `{"activityId":123456,"st_flpv":"` + testFlpv + `"}`
+	body := `{"q":"JL/dpwEgtMPTH7o9ez6uW5DbotKch3HmFHOk+3GaILe728tP04Q2LtKD5h3hn3sLG+YoTc1ulE1E+GYzy5ibLg=="}`
+	got, err := DecryptRequestBody(body, testTraceId, testToken)
+	if err != nil || got != plain {
+		t.Fatalf("DecryptRequestBody = %q, %v", got, err)
+	}
+
+	// 与发送请求时的加密方式一致
+	enc, err := AESEncrypt(plain, GenerateKey(testTraceId, testToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := DecryptRequestBody(`{"q":"`+enc+`"}`, testTraceId, testToken); err != nil || got != plain {
+		t.Errorf("DecryptRequestBody = %q, %v", got, err)
+	}
+
+	for _, bad := range []struct{ body, traceId string }{
+		{"not json", testTraceId},
+		{`{"q":""}`, testTraceId},
+		{`{"activityId":123456}`, testTraceId},
+		// traceId 不同时密钥不同，填充校验失败
+		{body, "fedcba9876543210fedcba9876543210" + "1723816800123"},
+		// 密钥长度不足 16 位
+		{body, "short"},
+	} {
+		if got, err := DecryptRequestBody(bad.body, bad.traceId, testToken); err == nil {
+			t.Errorf("DecryptRequestBody(%q, %q) = %q, want 错误", bad.body, bad.traceId, got)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// sensitiveKeys 需要脱敏的 header 与 JSON 字段（小写）
+var sensitiveKeys = map[string]bool{
+	// 账号的长期凭证：cdeviceno、cuuserref 即配置的 token，cusut 即 sign；
+	// token 与 crtraceid 可推导出请求体的加密密钥
+	"token":              true,
+	"sign":               true,
+	"cusut":              true,
+	"cuuserref":          true,
+	"cdeviceno":          true,
+	"cookie":             true,
+	"set-cookie":         true,
+	"cusat":              true,
+	"cusit":              true,
+	"access_token":       true,
+	"id_token":           true,
+	"accesstoken":        true,
+	"idtoken":            true,
+	"telephone":          true,
+	"phone":              true,
+	"mobile":             true,
+	"documentnumber":     true,
+	"showdocumentnumber": true,
+	"idcard":             true,
+}
+
+// IsSensitiveKey 判断字段或 header 是否需要脱敏
+func IsSensitiveKey(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// Mask 保留首尾少量字符，其余替换为 *
+func Mask(v string) string {
+	if v == "" || v == "nil" {
+		return v
+	}
+	r := []rune(v)
+	if len(r) < 8 {
+		return "***"
+	}
+	return string(r[:3]) + "****" + string(r[len(r)-2:])
+}
+
+// RedactHeader 复制 header，并对敏感字段的每个值脱敏
+func RedactHeader(h http.Header) http.Header {
+	res := h.Clone()
+	for key, values := range res {
+		if !IsSensitiveKey(key) {
+			continue
+		}
+		for i, v := range values {
+			values[i] = Mask(v)
+		}
+	}
+	return res
+}
+
+// RedactJSON 对 JSON 中的敏感字段脱敏；非 JSON 内容原样返回
+func RedactJSON(data []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return data
+	}
+	if !redactValue(v) {
+		return data
+	}
+	res, err := json.Marshal(v)
+	if err != nil {
+		return data
+	}
+	return res
+}
+
+// redactValue 原地脱敏，返回是否有修改
+func redactValue(v interface{}) bool {
+	changed := false
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, item := range val {
+			if IsSensitiveKey(key) {
+				if s, ok := item.(string); ok {
+					val[key] = Mask(s)
+					changed = true
+					continue
+				}
+				if item != nil {
+					if _, nested := item.(map[string]interface{}); !nested {
+						val[key] = "***"
+						changed = true
+						continue
+					}
+				}
+			}
+			if redactValue(item) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			if redactValue(item) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,105 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRedactHeader(t *testing.T) {
+	h := http.Header{}
+	secrets := map[string]string{
+		"cookie":    "sessionid=cookie-0123456789",
+		"cusat":     "access-token-0123456789",
+		"cusit":     "id-token-0123456789",
+		"cusut":     testSign,
+		"cuuserref": testToken,
+		"cdeviceno": testToken,
+	}
+	for key, value := range secrets {
+		h.Set(key, value)
+	}
+	// 多值 header 的每个值都需要脱敏
+	h.Add("set-cookie", "sessionid=session-0123456789; Path=/")
+	h.Add("set-cookie", "refresh=refresh-0123456789; Path=/")
+	h.Set("cterminal", "wap")
+	h.Set("crtraceid", "0123456789abcdef0123456789abcdef")
+
+	res := RedactHeader(h)
+	for key, value := range secrets {
+		got := res.Get(key)
+		if got == "" {
+			t.Errorf("缺少 header %s", key)
+			continue
+		}
+		if got == value || strings.Contains(got, value[3:len(value)-2]) {
+			t.Errorf("header %s 未脱敏: %s", key, got)
+		}
+	}
+	cookies := res.Values("set-cookie")
+	if len(cookies) != 2 {
+		t.Fatalf("set-cookie = %v, want 2 个值", cookies)
+	}
+	for _, v := range cookies {
+		if strings.Contains(v, "session-0123456789") || strings.Contains(v, "refresh-0123456789") {
+			t.Errorf("set-cookie 未脱敏: %s", v)
+		}
+	}
+	if res.Get("cterminal") != "wap" || res.Get("crtraceid") != "0123456789abcdef0123456789abcdef" {
+		t.Errorf("非敏感 header 被修改: %v", res)
+	}
+	// 不修改原 header
+	if h.Values("set-cookie")[1] != "refresh=refresh-0123456789; Path=/" {
+		t.Errorf("原 header 被修改: %v", h)
+	}
+}
+
+func TestRedactJSON(t *testing.T) {
+	body := `{"st_flpv":"` + testFlpv + `","sign":"` + testSign + `","token":"` + testToken + `",` +
+		`"telephone":"13800000000","orderDetails":[{"skuId":"ticket-388"}],` +
+		`"accessToken":{"access_token":"access-token-0123456789","expire":3600},` +
+		`"result":[{"name":"观演人1","showDocumentNumber":"110101199001011234"}]}`
+
+	out := RedactJSON([]byte(body))
+	for _, secret := range []string{testSign, testToken, "13800000000", "access-token-0123456789", "110101199001011234"} {
+		if strings.Contains(string(out), secret) {
+			t.Errorf("未脱敏 %q: %s", secret, out)
+		}
+	}
+
+	var v map[string]interface{}
+	if err := json.Unmarshal(out, &v); err != nil {
+		t.Fatalf("脱敏结果不是 JSON: %v", err)
+	}
+	if v["st_flpv"] != testFlpv {
+		t.Errorf("st_flpv 被修改: %v", v["st_flpv"])
+	}
+	if got := v["sign"]; got != Mask(testSign) {
+		t.Errorf("sign = %v, want %s", got, Mask(testSign))
+	}
+	if !strings.Contains(string(out), "ticket-388") {
+		t.Errorf("非敏感字段丢失: %s", out)
+	}
+}
+
+func TestRedactJSONKeepsNonJSON(t *testing.T) {
+	for _, in := range []string{"", "not json", `{"q":"abc"}`} {
+		if out := string(RedactJSON([]byte(in))); out != in {
+			t.Errorf("RedactJSON(%q) = %q", in, out)
+		}
+	}
+}
+
+func TestIsSensitiveKeyCaseInsensitive(t *testing.T) {
+	for _, key := range []string{"Sign", "TOKEN", "Cusut", "CuUserRef", "CDeviceNo", "Cookie", "Set-Cookie"} {
+		if !IsSensitiveKey(key) {
+			t.Errorf("%s 应脱敏", key)
+		}
+	}
+	for _, key := range []string{"st_flpv", "cterminal", "crpsign", "skuId"} {
+		if IsSensitiveKey(key) {
+			t.Errorf("%s 不应脱敏", key)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
+	"net/http"
 )
 
 type GenerateSignReq struct {
@@ -16,13 +17,58 @@ type GenerateSignReq struct {
 	TraceId   string `json:"traceId"`
 	Token     string `json:"token"`
 	Cterminal string `json:"cterminal"`
+	Cversion  string `json:"cversion"`
+	Csappid   string `json:"csappid"`
 }
 
-func GenerateSign(req *GenerateSignReq) string {
-	o := fmt.Sprintf(`%s%s%s%s%s%s%s%s%s%s%s`, req.Cusat, req.Sign, req.Cusit, req.Cusid, "wap", req.Token, req.Data, req.Path, "997", req.Cterminal, req.TraceId)
+// Signer 计算请求头 crpsign
+type Signer interface {
+	Sign(req *GenerateSignReq) string
+}
+
+// HeaderSigner 按网页端规则签名，cterminal、cversion、csappid 取自实际发送的 header
+type HeaderSigner struct{}
+
+func (HeaderSigner) Sign(req *GenerateSignReq) string {
+	csappid := req.Csappid
+	if csappid == "" {
+		csappid = req.Cterminal
+	}
+	o := fmt.Sprintf(`%s%s%s%s%s%s%s%s%s%s%s`, req.Cusat, req.Sign, req.Cusit, req.Cusid, req.Cterminal, req.Token, req.Data, req.Path, req.Cversion, csappid, req.TraceId)
 	return Md5Hex(o)
 }
 
+// DefaultSigner 默认的签名方式
+var DefaultSigner Signer = HeaderSigner{}
+
+func GenerateSign(req *GenerateSignReq) string {
+	return DefaultSigner.Sign(req)
+}
+
+// SignReqFromHeader 使用请求实际携带的 header 构造签名参数，token 为空时 header 中的 "nil" 按空字符串计算
+func SignReqFromHeader(path, data string, h http.Header) *GenerateSignReq {
+	return &GenerateSignReq{
+		Path:      path,
+		Data:      data,
+		Cusat:     nilToEmpty(h.Get("cusat")),
+		Sign:      h.Get("cusut"),
+		Cusit:     nilToEmpty(h.Get("cusit")),
+		Cusid:     h.Get("cusid"),
+		TraceId:   h.Get("crtraceid"),
+		Token:     h.Get("cdeviceno"),
+		Cterminal: h.Get("cterminal"),
+		Cversion:  h.Get("cversion"),
+		Csappid:   h.Get("csappid"),
+	}
+}
+
+func nilToEmpty(v string) string {
+	if v == "nil" {
+		return ""
+	}
+	return v
+}
+
 func Md5Hex(n string) string {
 	// Compute MD5 hash
 	hasher := md5.New()
@@ -0,0 +1,88 @@
+package util
+
+import (
+	"net/http"
+	"testing"
+)
+
+const (
+	testToken = "abcdefghijklmnopqrstuvwxyz0123456789"
+	testSign  = "f65f2dbcd7387b2376bcefe13754856b"
+	testFlpv  = "kgUBxCv3t7EN1wWr6Yeo"
+)
+
+// signVectors 固定的 header 与期望的 crpsign。期望值按网页端规则独立计算（md5 拼接顺序：
+// cusat sign cusit cusid cterminal token data path cversion csappid traceId），
+// wap/997 一项与改为读取 header 之前硬编码 "wap"、"997" 的签名一致
+var signVectors = []struct {
+	name   string
+	path   string
+	data   string
+	header map[string]string
+	want   string
+}{
+	{
+		name: "wap/997 基线",
+		path: "/wap/activity/details",
+		data: `{"activityId":"123456","coupon":"","shareId":"","st_flpv":"` + testFlpv + `","sign":"` + testSign + `","trackPath":""}`,
+		header: map[string]string{
+			"cusat": "at-1", "cusut": testSign, "cusit": "it-1", "cusid": "1",
+			"cterminal": "wap", "csappid": "wap", "cversion": "997",
+			"cdeviceno": testToken, "crtraceid": "0123456789abcdef0123456789abcdef",
+		},
+		want: "c447d05dd5de4d5334ae6bc5355abc09",
+	},
+	{
+		name: "未登录时 cusat/cusit 为 nil",
+		path: "/waf/gettoken",
+		data: `{"st_flpv":"` + testFlpv + `","sign":"` + testSign + `","trackPath":""}`,
+		header: map[string]string{
+			"cusat": "nil", "cusut": testSign, "cusit": "nil", "cusid": "1",
+			"cterminal": "wap", "csappid": "wap", "cversion": "997",
+			"cdeviceno": testToken, "crtraceid": "0123456789abcdef0123456789abcdef",
+		},
+		want: "c31310e9cfed11737009c97efb571902",
+	},
+	{
+		name: "cterminal/cversion/csappid 取自 header",
+		path: "/nj/order/order",
+		data: `{"q":"abc"}`,
+		header: map[string]string{
+			"cusat": "at-1", "cusut": testSign, "cusit": "it-1", "cusid": "1",
+			"cterminal": "app", "csappid": "app-id", "cversion": "998",
+			"cdeviceno": testToken, "crtraceid": "fedcba9876543210fedcba9876543210",
+		},
+		want: "1d151979eac79e0e81b752b4874b550c",
+	},
+}
+
+func TestHeaderSignerVectors(t *testing.T) {
+	for _, v := range signVectors {
+		t.Run(v.name, func(t *testing.T) {
+			h := http.Header{}
+			for key, value := range v.header {
+				h.Set(key, value)
+			}
+			req := SignReqFromHeader(v.path, v.data, h)
+			if got := (HeaderSigner{}).Sign(req); got != v.want {
+				t.Errorf("crpsign = %s, want %s", got, v.want)
+			}
+			if got := GenerateSign(req); got != v.want {
+				t.Errorf("GenerateSign = %s, want %s", got, v.want)
+			}
+		})
+	}
+}
+
+func TestHeaderSignerDefaultsCsappid(t *testing.T) {
+	v := signVectors[0]
+	h := http.Header{}
+	for key, value := range v.header {
+		h.Set(key, value)
+	}
+	h.Del("csappid")
+	// 未携带 csappid 时按 cterminal 计算，与基线一致
+	if got := (HeaderSigner{}).Sign(SignReqFromHeader(v.path, v.data, h)); got != v.want {
+		t.Errorf("crpsign = %s, want %s", got, v.want)
+	}
+}
@@ -0,0 +1,158 @@
+package vars
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SaleStatus 票档的售卖状态（ticketList 中的 saleStatus）
+type SaleStatus int
+
+const (
+	SaleStatusUnknown             SaleStatus = 0
+	SaleStatusBuyNow              SaleStatus = 1
+	SaleStatusBuyNowAlt           SaleStatus = 2
+	SaleStatusSoldOut             SaleStatus = 3
+	SaleStatusComingSoon          SaleStatus = 4
+	SaleStatusActivityEnded       SaleStatus = 5
+	SaleStatusSaleEnded           SaleStatus = 6
+	SaleStatusDisplayOnly         SaleStatus = 7
+	SaleStatusAppReminder         SaleStatus = 8
+	SaleStatusAppWaitlist         SaleStatus = 9
+	SaleStatusAppShortageRegister SaleStatus = 10
+	SaleStatusAppPurchase         SaleStatus = 11
+	SaleStatusWaitlistExceeded    SaleStatus = 12
+	SaleStatusAppWaitlistAlt      SaleStatus = 13
+	SaleStatusWaitlistJoined      SaleStatus = 14
+)
+
+var saleStatusNames = map[SaleStatus]string{
+	SaleStatusBuyNow:              "立即购买",
+	SaleStatusBuyNowAlt:           "立即购买",
+	SaleStatusSoldOut:             "票已售罄",
+	SaleStatusComingSoon:          "即将开售",
+	SaleStatusActivityEnded:       "活动结束",
+	SaleStatusSaleEnded:           "售票结束",
+	SaleStatusDisplayOnly:         "仅供展示",
+	SaleStatusAppReminder:         "APP开票提醒",
+	SaleStatusAppWaitlist:         "APP候补购票",
+	SaleStatusAppShortageRegister: "APP缺票登记",
+	SaleStatusAppPurchase:         "APP购票",
+	SaleStatusWaitlistExceeded:    "已超过候补限制",
+	SaleStatusAppWaitlistAlt:      "APP候补购票",
+	SaleStatusWaitlistJoined:      "已参与候补",
+}
+
+func (s SaleStatus) String() string {
+	if name, ok := saleStatusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("未知售卖状态(%d)", int(s))
+}
+
+// Known 是否为已收录的状态
+func (s SaleStatus) Known() bool {
+	_, ok := saleStatusNames[s]
+	return ok
+}
+
+// Purchasable 网页端能否直接购买；未收录的状态返回错误
+func (s SaleStatus) Purchasable() (bool, error) {
+	if !s.Known() {
+		return false, fmt.Errorf("未收录的 saleStatus：%d", int(s))
+	}
+	return s == SaleStatusBuyNow || s == SaleStatusBuyNowAlt, nil
+}
+
+// BuyType 购票方式（confirm 中的 buyType），决定是否需要选择观演人
+type BuyType int
+
+const (
+	BuyTypeUnknown BuyType = 0
+	// BuyTypeRealName 实名制，需要选择观演人
+	BuyTypeRealName BuyType = 2
+	// BuyTypeNonRealName 无需选择观演人
+	BuyTypeNonRealName    BuyType = 3
+	BuyTypeNonRealNameAlt BuyType = 4
+)
+
+var buyTypeNames = map[BuyType]string{
+	BuyTypeRealName:       "实名制",
+	BuyTypeNonRealName:    "非实名",
+	BuyTypeNonRealNameAlt: "非实名",
+}
+
+func (t BuyType) String() string {
+	if name, ok := buyTypeNames[t]; ok {
+		return fmt.Sprintf("%s(%d)", name, int(t))
+	}
+	return fmt.Sprintf("未知购票方式(%d)", int(t))
+}
+
+// Known 是否为已收录的购票方式
+func (t BuyType) Known() bool {
+	_, ok := buyTypeNames[t]
+	return ok
+}
+
+// NeedAudience 是否需要选择观演人；未收录的购票方式返回错误，由调用方决定如何处理
+func (t BuyType) NeedAudience() (bool, error) {
+	if !t.Known() {
+		return false, fmt.Errorf("未收录的 buyType：%d", int(t))
+	}
+	return t == BuyTypeRealName, nil
+}
+
+// TicketType 票的类型（ticketType），决定是否需要填写收货地址。
+// ticketList 中为字符串，confirm 中为数字，两者都可以解析
+type TicketType int
+
+const (
+	TicketTypeUnknown TicketType = 0
+	// TicketTypeElectronic 电子票，无需地址
+	TicketTypeElectronic TicketType = 1
+	// TicketTypeExpress 快递票，需要收货地址
+	TicketTypeExpress TicketType = 2
+)
+
+var ticketTypeNames = map[TicketType]string{
+	TicketTypeElectronic: "电子票",
+	TicketTypeExpress:    "快递票",
+}
+
+func (t TicketType) String() string {
+	if name, ok := ticketTypeNames[t]; ok {
+		return fmt.Sprintf("%s(%d)", name, int(t))
+	}
+	return fmt.Sprintf("未知票类型(%d)", int(t))
+}
+
+// Known 是否为已收录的票类型
+func (t TicketType) Known() bool {
+	_, ok := ticketTypeNames[t]
+	return ok
+}
+
+// NeedAddress 是否需要填写地址；未收录的票类型返回错误，由调用方决定如何处理
+func (t TicketType) NeedAddress() (bool, error) {
+	if !t.Known() {
+		return false, fmt.Errorf("未收录的 ticketType：%d", int(t))
+	}
+	return t == TicketTypeExpress, nil
+}
+
+// UnmarshalJSON 兼容数字、数字字符串与空值
+func (t *TicketType) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(strings.TrimSpace(string(data)), `"`)
+	if s == "" || s == "null" {
+		*t = TicketTypeUnknown
+		return nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("无法解析 ticketType %s：%w", string(data), err)
+	}
+	*t = TicketType(v)
+	return nil
+}
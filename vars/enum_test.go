@@ -0,0 +1,118 @@
+package vars
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTicketTypeUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    TicketType
+		wantErr bool
+	}{
+		{in: `1`, want: TicketTypeElectronic},
+		{in: `2`, want: TicketTypeExpress},
+		// ticketList 中为字符串
+		{in: `"1"`, want: TicketTypeElectronic},
+		{in: `"2"`, want: TicketTypeExpress},
+		{in: `""`, want: TicketTypeUnknown},
+		{in: `null`, want: TicketTypeUnknown},
+		// 未收录的值照常解析，由 Known/NeedAddress 判断
+		{in: `7`, want: TicketType(7)},
+		{in: `"abc"`, wantErr: true},
+		{in: `1.5`, wantErr: true},
+		{in: `true`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var v struct {
+			TicketType TicketType `json:"ticketType"`
+		}
+		err := json.Unmarshal([]byte(`{"ticketType":`+tt.in+`}`), &v)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: 应返回错误, got %v", tt.in, v.TicketType)
+			}
+			continue
+		}
+		if err != nil || v.TicketType != tt.want {
+			t.Errorf("%s: got %v, %v, want %v", tt.in, v.TicketType, err, tt.want)
+		}
+	}
+}
+
+func TestTicketTypeNeedAddress(t *testing.T) {
+	tests := []struct {
+		t       TicketType
+		want    bool
+		wantErr bool
+	}{
+		{TicketTypeElectronic, false, false},
+		{TicketTypeExpress, true, false},
+		{TicketTypeUnknown, false, true},
+		{TicketType(7), false, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.t.NeedAddress()
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%v.NeedAddress() = %v, %v", tt.t, got, err)
+		}
+		if tt.t.Known() == tt.wantErr {
+			t.Errorf("%v.Known() = %v", tt.t, tt.t.Known())
+		}
+	}
+	if s := TicketType(7).String(); s != "未知票类型(7)" {
+		t.Errorf("String = %s", s)
+	}
+}
+
+func TestBuyTypeNeedAudience(t *testing.T) {
+	tests := []struct {
+		t       BuyType
+		want    bool
+		wantErr bool
+	}{
+		{BuyTypeRealName, true, false},
+		{BuyTypeNonRealName, false, false},
+		{BuyTypeNonRealNameAlt, false, false},
+		{BuyTypeUnknown, false, true},
+		{BuyType(1), false, true},
+		{BuyType(5), false, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.t.NeedAudience()
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%v.NeedAudience() = %v, %v", tt.t, got, err)
+		}
+		if tt.t.Known() == tt.wantErr {
+			t.Errorf("%v.Known() = %v", tt.t, tt.t.Known())
+		}
+	}
+	if s := BuyTypeRealName.String(); s != "实名制(2)" {
+		t.Errorf("String = %s", s)
+	}
+}
+
+func TestSaleStatusPurchasable(t *testing.T) {
+	tests := []struct {
+		s       SaleStatus
+		want    bool
+		wantErr bool
+	}{
+		{SaleStatusBuyNow, true, false},
+		{SaleStatusBuyNowAlt, true, false},
+		{SaleStatusSoldOut, false, false},
+		{SaleStatusComingSoon, false, false},
+		{SaleStatusUnknown, false, true},
+		{SaleStatus(99), false, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.s.Purchasable()
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%v.Purchasable() = %v, %v", tt.s, got, err)
+		}
+	}
+	if s := SaleStatus(99).String(); s != "未知售卖状态(99)" {
+		t.Errorf("String = %s", s)
+	}
+}